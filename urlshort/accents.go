@@ -0,0 +1,20 @@
+package urlshort
+
+import (
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldAccents returns path with its diacritics removed, as
+// described on Options.AccentInsensitive.
+func foldAccents(path string) string {
+	folder := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(folder, path)
+	if err != nil {
+		return path
+	}
+	return folded
+}
@@ -0,0 +1,39 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAccentInsensitive(t *testing.T) {
+	urls := ShortenedUrls{{Path: "/café", Url: "https://example.com/cafe"}}
+	tests := []struct {
+		name  string
+		opts  Options
+		cases []redirectCase
+	}{
+		{"off", Options{}, []redirectCase{
+			{name: "accented", target: "/caf%C3%A9", status: http.StatusMovedPermanently, location: "https://example.com/cafe"},
+			{name: "plain", target: "/cafe", status: http.StatusNotFound},
+		}},
+		{"on", Options{AccentInsensitive: true}, []redirectCase{
+			{name: "accented", target: "/caf%C3%A9", status: http.StatusMovedPermanently, location: "https://example.com/cafe"},
+			{name: "plain", target: "/cafe", status: http.StatusMovedPermanently, location: "https://example.com/cafe"},
+			{name: "decomposed", target: "/cafe%CC%81", status: http.StatusMovedPermanently, location: "https://example.com/cafe"},
+			{name: "case kept", target: "/CAFE", status: http.StatusNotFound},
+		}},
+		{"with case", Options{AccentInsensitive: true, CaseInsensitive: true}, []redirectCase{
+			{name: "upper plain", target: "/CAFE", status: http.StatusMovedPermanently, location: "https://example.com/cafe"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Logger = discardLogger
+			handler, err := UrlsHandler(urls, tt.opts, http.NotFoundHandler())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRedirects(t, handler, tt.cases)
+		})
+	}
+}
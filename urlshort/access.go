@@ -0,0 +1,51 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LastAccessHandler is an http.Handler that behaves like the
+// one returned by MapHandler, and also records when each path
+// was last redirected, to help find stale links. Requests
+// passed to the fallback are not recorded. It is safe for
+// concurrent use.
+type LastAccessHandler struct {
+	handler *pathRedirector
+	last    sync.Map
+}
+
+// NewLastAccessHandler returns a LastAccessHandler for the
+// given mappings. If a path is not mapped, then the fallback
+// http.Handler will be called instead.
+func NewLastAccessHandler(pathsToUrls map[string]string, fallback http.Handler) *LastAccessHandler {
+	lh := &LastAccessHandler{}
+	opts := Options{OnRedirect: lh.record}
+	lh.handler = newLenientMapHandler(pathsToUrls, opts, fallback)
+	return lh
+}
+
+func (lh *LastAccessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lh.handler.ServeHTTP(w, r)
+}
+
+// Match implements Matcher.
+func (lh *LastAccessHandler) Match(r *http.Request) (string, bool) {
+	return lh.handler.Match(r)
+}
+
+// LastAccessed returns a snapshot of when each path was last
+// redirected. Paths never requested are not included.
+func (lh *LastAccessHandler) LastAccessed() map[string]time.Time {
+	last := map[string]time.Time{}
+	lh.last.Range(func(key, value any) bool {
+		last[key.(string)] = value.(time.Time)
+		return true
+	})
+	return last
+}
+
+func (lh *LastAccessHandler) record(r *http.Request, key, url string) {
+	lh.last.Store(key, lh.handler.opts.now())
+}
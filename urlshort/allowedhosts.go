@@ -0,0 +1,77 @@
+package urlshort
+
+import (
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// checkHosts returns urls without the entries pointing to a
+// host outside AllowedHosts, if SkipDisallowedHosts is set, or
+// else a *ConfigError listing every such entry.
+func (o Options) checkHosts(urls ShortenedUrls) (ShortenedUrls, error) {
+	configErr := &ConfigError{}
+	allowed := make(ShortenedUrls, 0, len(urls))
+	for i, entry := range urls {
+		rawUrl, host, ok := o.disallowedHost(entry)
+		if ok {
+			allowed = append(allowed, entry)
+			continue
+		}
+		if o.SkipDisallowedHosts {
+			slog.Warn("Skipping entry pointing to a host not allowed",
+				slog.String("path", entry.Path), slog.String("url", rawUrl), slog.String("host", host))
+			continue
+		}
+		if host == "" {
+			configErr.add(i, entry.Path, "url '%s' cannot be parsed to check its host", rawUrl)
+			continue
+		}
+		configErr.add(i, entry.Path, "url '%s' points to host '%s', which is not allowed", rawUrl, host)
+	}
+	if err := configErr.err(); err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+	return allowed, nil
+}
+
+// disallowedHost returns the first url of entry pointing to a
+// host outside AllowedHosts, with that host and false, or true
+// as its last result if every url is allowed.
+func (o Options) disallowedHost(entry ShortenedUrl) (string, string, bool) {
+	if entry.Gone || !entry.IsEnabled() {
+		return "", "", true
+	}
+	for _, rawUrl := range entryUrls(entry) {
+		host, ok := destinationHost(o.resolve(rawUrl))
+		if !ok || host != "" && !o.hostAllowed(host) {
+			return rawUrl, host, false
+		}
+	}
+	return "", "", true
+}
+
+// hostAllowed reports whether host, or a wildcard matching it,
+// is in AllowedHosts.
+func (o Options) hostAllowed(host string) bool {
+	for _, candidate := range hostCandidates(host) {
+		if candidate != "" && o.allowedHosts[candidate] {
+			return true
+		}
+	}
+	return false
+}
+
+// destinationHost returns the lowercase host of rawUrl, without
+// any port, or "" if it has none. Browsers read backslashes as
+// slashes, so /\evil.com goes to evil.com, and so does it here.
+// It reports false if rawUrl cannot be parsed, leaving its host
+// unknown.
+func destinationHost(rawUrl string) (string, bool) {
+	u, err := url.Parse(strings.ReplaceAll(rawUrl, "\\", "/"))
+	if err != nil {
+		return "", false
+	}
+	return strings.ToLower(u.Hostname()), true
+}
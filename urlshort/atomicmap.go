@@ -0,0 +1,58 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// AtomicMapHandler is an http.Handler that behaves like the one
+// returned by MapHandler, but whose whole map can be swapped
+// for another with Store while it serves requests, without
+// swapping the handler in a router. Lookups take no lock, so
+// reads cost the same as with MapHandler. It is safe for
+// concurrent use.
+//
+// Store is atomic: a request sees either the mappings from
+// before a call to Store or those after it, never a mix, and
+// every request starting after Store returns sees the new ones,
+// as Store happens before every load that observes it in the
+// sense of the Go memory model.
+type AtomicMapHandler struct {
+	redirector
+	entries atomic.Pointer[map[string]ShortenedUrl]
+}
+
+// NewAtomicMapHandler returns an AtomicMapHandler serving the
+// mappings in pathsToUrls, which may be nil. If a path is not
+// mapped, then the fallback http.Handler will be called
+// instead.
+func NewAtomicMapHandler(pathsToUrls map[string]string, fallback http.Handler) *AtomicMapHandler {
+	opts, _ := Options{}.withDefaults()
+	ah := &AtomicMapHandler{
+		redirector: redirector{opts: opts, fallback: fallback},
+	}
+	ah.Store(pathsToUrls)
+	return ah
+}
+
+// Store replaces every mapping with those in pathsToUrls, all at
+// once. The map is copied, so later changes to it do not affect
+// the handler, and it can be reused once Store returns.
+func (ah *AtomicMapHandler) Store(pathsToUrls map[string]string) {
+	entries := ah.opts.mapEntries(pathsToUrls)
+	ah.entries.Store(&entries)
+}
+
+func (ah *AtomicMapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ah.serve(w, r, ah.lookup)
+}
+
+// Match implements Matcher.
+func (ah *AtomicMapHandler) Match(r *http.Request) (string, bool) {
+	return ah.matchRequest(r, ah.lookup)
+}
+
+func (ah *AtomicMapHandler) lookup(key string) (ShortenedUrl, bool) {
+	entry, exists := (*ah.entries.Load())[key]
+	return entry, exists
+}
@@ -0,0 +1,105 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAtomicMapHandlerStore(t *testing.T) {
+	handler := NewAtomicMapHandler(map[string]string{"/a": "https://a.example.com"}, http.NotFoundHandler())
+	handler.Store(map[string]string{"/b": "https://b.example.com"})
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/a", http.StatusNotFound, ""},
+		{"/b", http.StatusMovedPermanently, "https://b.example.com"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.path, rec.Code, tt.status)
+		}
+		if got := rec.Header().Get("Location"); got != tt.location {
+			t.Errorf("%s: Location = %q, want %q", tt.path, got, tt.location)
+		}
+	}
+}
+
+func benchmarkMatchers(n int) (map[string]string, map[string]Matcher) {
+	pathsToUrls := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		pathsToUrls[fmt.Sprintf("/link-%d", i)] = fmt.Sprintf("https://example.com/%d", i)
+	}
+	fallback := http.NotFoundHandler()
+	return pathsToUrls, map[string]Matcher{
+		"atomic":  NewAtomicMapHandler(pathsToUrls, fallback),
+		"rwmutex": NewMutableHandler(pathsToUrls, fallback),
+	}
+}
+
+// BenchmarkConcurrentLookup compares lock-free lookups in
+// AtomicMapHandler with the read lock MutableHandler takes.
+func BenchmarkConcurrentLookup(b *testing.B) {
+	_, matchers := benchmarkMatchers(1000)
+	for _, name := range []string{"atomic", "rwmutex"} {
+		matcher := matchers[name]
+		b.Run(name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				r := httptest.NewRequest(http.MethodGet, "/link-500", nil)
+				for pb.Next() {
+					if _, ok := matcher.Match(r); !ok {
+						b.Fatal("no match")
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkLookupDuringUpdates measures lookups while another
+// goroutine keeps replacing the mappings.
+func BenchmarkLookupDuringUpdates(b *testing.B) {
+	pathsToUrls, matchers := benchmarkMatchers(1000)
+	updates := map[string]func(){
+		"atomic": func() {
+			matchers["atomic"].(*AtomicMapHandler).Store(pathsToUrls)
+		},
+		"rwmutex": func() {
+			matchers["rwmutex"].(*MutableHandler).Set("/link-0", "https://example.com/0")
+		},
+	}
+	for _, name := range []string{"atomic", "rwmutex"} {
+		matcher, update := matchers[name], updates[name]
+		b.Run(name, func(b *testing.B) {
+			done := make(chan struct{})
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+				for {
+					select {
+					case <-done:
+						return
+					default:
+						update()
+					}
+				}
+			}()
+			b.RunParallel(func(pb *testing.PB) {
+				r := httptest.NewRequest(http.MethodGet, "/link-500", nil)
+				for pb.Next() {
+					if _, ok := matcher.Match(r); !ok {
+						b.Fatal("no match")
+					}
+				}
+			})
+			close(done)
+			<-stopped
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package urlshort
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// largeUrls returns n distinct mappings, as found in a large
+// config.
+func largeUrls(n int) ShortenedUrls {
+	urls := make(ShortenedUrls, n)
+	for i := range urls {
+		urls[i] = ShortenedUrl{
+			Path: fmt.Sprintf("/link-%d", i),
+			Url:  fmt.Sprintf("https://example.com/target/%d", i),
+		}
+	}
+	return urls
+}
+
+// discardLogger drops everything, keeping request logs out of
+// test and benchmark output.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func BenchmarkBuildEntries(b *testing.B) {
+	for _, n := range []int{1000, 500000} {
+		urls := largeUrls(n)
+		opts, _ := Options{}.withDefaults()
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := opts.buildEntries(urls); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	for _, n := range []int{10, 1000, 500000} {
+		urls := largeUrls(n)
+		opts, _ := Options{}.withDefaults()
+		entries, err := opts.buildEntries(urls)
+		if err != nil {
+			b.Fatal(err)
+		}
+		// Look up the last entry, the worst case for a scan.
+		key := opts.entryKey(urls[n-1].Path)
+
+		b.Run(fmt.Sprintf("map/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, exists := entries[key]; !exists {
+					b.Fatal("not found")
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("slice/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				found := false
+				for _, entry := range urls {
+					if entry.Path == key {
+						found = true
+						break
+					}
+				}
+				if !found {
+					b.Fatal("not found")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkHandlerLookup(b *testing.B) {
+	for _, n := range []int{1000, 500000} {
+		urls := largeUrls(n)
+		handler, err := UrlsHandler(urls, Options{Logger: discardLogger}, http.NotFoundHandler())
+		if err != nil {
+			b.Fatal(err)
+		}
+		r := httptest.NewRequest(http.MethodGet, urls[n/2].Path, nil)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				handler.ServeHTTP(httptest.NewRecorder(), r)
+			}
+		})
+	}
+}
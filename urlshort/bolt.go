@@ -0,0 +1,91 @@
+package urlshort
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.etcd.io/bbolt"
+)
+
+type boltRedirector struct {
+	redirector
+	db     *bbolt.DB
+	bucket []byte
+}
+
+// BoltHandler will return an http.Handler that looks up the
+// url for each request in the given bucket of a bbolt
+// database, using the request path as the key. If the key does
+// not exist, then the fallback http.Handler will be called
+// instead.
+//
+// An error is returned if the bucket does not exist. Use
+// SeedBolt to create and fill it.
+func BoltHandler(db *bbolt.DB, bucket string, fallback http.Handler) (http.Handler, error) {
+	err := db.View(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte(bucket)) == nil {
+			return fmt.Errorf("bucket '%s' not found", bucket)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	opts, _ := Options{}.withDefaults()
+	return &boltRedirector{
+		redirector: redirector{opts: opts, fallback: fallback},
+		db:         db,
+		bucket:     []byte(bucket),
+	}, nil
+}
+
+// SeedBolt stores urls in the given bucket of a bbolt database,
+// creating the bucket if needed, in a single transaction. Each
+// alias in Paths is stored as well, and paths are stored in the
+// form handlers with default options look them up in. Paths
+// already in the bucket are overwritten.
+func SeedBolt(db *bbolt.DB, bucket string, urls ShortenedUrls) error {
+	opts, _ := Options{}.withDefaults()
+	return db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		for _, url := range urls {
+			for _, path := range entryPaths(url) {
+				if err := b.Put([]byte(opts.entryKey(path)), []byte(url.Url)); err != nil {
+					return fmt.Errorf("path '%s': %w", path, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (br *boltRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	br.serve(w, r, br.lookupIn(r.Context(), br, "bbolt"))
+}
+
+// Match implements Matcher.
+func (br *boltRedirector) Match(r *http.Request) (string, bool) {
+	return br.matchRequest(r, br.lookupIn(r.Context(), br, "bbolt"))
+}
+
+// Lookup implements Store by reading path from the bucket.
+func (br *boltRedirector) Lookup(ctx context.Context, path string) (string, bool, error) {
+	var url string
+	var exists bool
+	err := br.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(br.bucket)
+		if b == nil {
+			return fmt.Errorf("bucket '%s' not found", br.bucket)
+		}
+		// The value is only valid during the transaction, so copy it.
+		if value := b.Get([]byte(path)); value != nil {
+			url, exists = string(value), true
+		}
+		return nil
+	})
+	return url, exists, err
+}
@@ -0,0 +1,101 @@
+package urlshort
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/bbolt"
+)
+
+// openBolt opens a bbolt database in a temporary directory,
+// closed when the test ends.
+func openBolt(t *testing.T) *bbolt.DB {
+	t.Helper()
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "urls.db"), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSeedBoltAliases(t *testing.T) {
+	db := openBolt(t)
+	err := SeedBolt(db, "urls", ShortenedUrls{
+		{Path: "/gh", Paths: []string{"/github"}, Url: "https://github.com"},
+		{Paths: []string{"/docs", "/d%C3%A9"}, Url: "https://example.com/docs"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, err := BoltHandler(db, "urls", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "path", target: "/gh", status: http.StatusMovedPermanently, location: "https://github.com"},
+		{name: "alias", target: "/github", status: http.StatusMovedPermanently, location: "https://github.com"},
+		{name: "alias only", target: "/docs", status: http.StatusMovedPermanently, location: "https://example.com/docs"},
+		{name: "encoded alias", target: "/d%C3%A9", status: http.StatusMovedPermanently, location: "https://example.com/docs"},
+		{name: "miss", target: "/missing", status: http.StatusNotFound},
+	})
+}
+
+func TestBoltHandler(t *testing.T) {
+	db := openBolt(t)
+	if err := SeedBolt(db, "urls", ShortenedUrls{{Path: "/a", Url: "https://example.com/old"}}); err != nil {
+		t.Fatal(err)
+	}
+	// Seeding again overwrites paths already in the bucket.
+	if err := SeedBolt(db, "urls", ShortenedUrls{
+		{Path: "/a", Url: "https://example.com/a"},
+		{Path: "/b", Url: "https://example.com/b"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	handler, err := BoltHandler(db, "urls", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "overwritten", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "mapped", target: "/b", status: http.StatusMovedPermanently, location: "https://example.com/b"},
+		{name: "miss", target: "/c", status: http.StatusNotFound},
+	})
+
+	// Keys added later are visible right away.
+	if err := SeedBolt(db, "urls", ShortenedUrls{{Path: "/c", Url: "https://example.com/c"}}); err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "added", target: "/c", status: http.StatusMovedPermanently, location: "https://example.com/c"},
+	})
+}
+
+func TestBoltHandlerErrors(t *testing.T) {
+	db := openBolt(t)
+	if _, err := BoltHandler(db, "missing", http.NotFoundHandler()); err == nil {
+		t.Error("BoltHandler(missing bucket) = nil error")
+	}
+
+	if err := SeedBolt(db, "urls", nil); err != nil {
+		t.Fatal(err)
+	}
+	handler, err := BoltHandler(db, "urls", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A bucket deleted after the handler was built is a lookup
+	// error, which is logged and treated as a miss.
+	if err := db.Update(func(tx *bbolt.Tx) error { return tx.DeleteBucket([]byte("urls")) }); err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "deleted bucket", target: "/a", status: http.StatusNotFound},
+	})
+	if _, _, err := handler.(Store).Lookup(context.Background(), "/a"); err == nil {
+		t.Error("Lookup(deleted bucket) = nil error")
+	}
+}
@@ -0,0 +1,68 @@
+package urlshort
+
+import "net/http"
+
+// Matcher is implemented by handlers that can tell, without
+// serving it, whether they would redirect a request or pass it
+// to their fallback. Every handler in this package implements
+// it.
+type Matcher interface {
+	http.Handler
+
+	// Match returns the url r would be redirected to, and true,
+	// or false if r would be passed to the fallback instead. The
+	// url is empty when r matches an entry that is gone.
+	Match(r *http.Request) (string, bool)
+}
+
+type chainHandler struct {
+	handlers []http.Handler
+}
+
+// ChainHandler returns an http.Handler that tries each of the
+// handlers in order, and lets the first one that matches the
+// request serve it. A handler that implements Matcher is
+// skipped when its Match method reports a miss. A handler that
+// does not implement Matcher always matches, so handlers after
+// it are never tried.
+//
+// When no handler matches, the last one serves the request,
+// which for a handler from this package means calling its
+// fallback. The fallbacks of the other handlers are never used.
+// If no handlers are given, every request gets a 404.
+func ChainHandler(handlers ...http.Handler) http.Handler {
+	return &chainHandler{handlers: handlers}
+}
+
+func (ch *chainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(ch.handlers) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	for _, handler := range ch.handlers[:len(ch.handlers)-1] {
+		if matcher, ok := handler.(Matcher); ok {
+			if _, matched := matcher.Match(r); !matched {
+				continue
+			}
+		}
+		handler.ServeHTTP(w, r)
+		return
+	}
+	ch.handlers[len(ch.handlers)-1].ServeHTTP(w, r)
+}
+
+// Match implements Matcher, reporting a match if any of the
+// handlers matches. The url is empty when the handler that
+// matches does not implement Matcher.
+func (ch *chainHandler) Match(r *http.Request) (string, bool) {
+	for _, handler := range ch.handlers {
+		matcher, ok := handler.(Matcher)
+		if !ok {
+			return "", true
+		}
+		if url, matched := matcher.Match(r); matched {
+			return url, true
+		}
+	}
+	return "", false
+}
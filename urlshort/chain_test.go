@@ -0,0 +1,73 @@
+package urlshort
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// namedFallback answers every request with a 404 and its name in
+// the body, to tell which fallback served a request.
+func namedFallback(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, name)
+	})
+}
+
+func TestChainHandler(t *testing.T) {
+	first := MapHandler(map[string]string{"/a": "https://example.com/first-a"}, namedFallback("first"))
+	second := MapHandler(map[string]string{
+		"/a": "https://example.com/second-a",
+		"/b": "https://example.com/b",
+	}, namedFallback("second"))
+	chain := ChainHandler(first, second)
+	checkRedirects(t, chain, []redirectCase{
+		{name: "first wins", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/first-a"},
+		{name: "second", target: "/b", status: http.StatusMovedPermanently, location: "https://example.com/b"},
+	})
+
+	rr := serveCase(chain, redirectCase{target: "/c"})
+	if rr.Code != http.StatusNotFound || rr.Body.String() != "second" {
+		t.Errorf("miss served with %d %q, want the fallback of the last handler", rr.Code, rr.Body.String())
+	}
+
+	matcher := chain.(Matcher)
+	for _, tc := range []struct {
+		target, url string
+		matched     bool
+	}{
+		{target: "/a", url: "https://example.com/first-a", matched: true},
+		{target: "/b", url: "https://example.com/b", matched: true},
+		{target: "/c"},
+	} {
+		if url, matched := matcher.Match(httptest.NewRequest(http.MethodGet, tc.target, nil)); url != tc.url || matched != tc.matched {
+			t.Errorf("Match(%s) = %q, %v, want %q, %v", tc.target, url, matched, tc.url, tc.matched)
+		}
+	}
+}
+
+func TestChainHandlerNonMatcher(t *testing.T) {
+	mapped := MapHandler(map[string]string{"/a": "https://example.com/a"}, namedFallback("mapped"))
+	chain := ChainHandler(namedFallback("plain"), mapped)
+
+	// A handler that is not a Matcher always matches.
+	rr := serveCase(chain, redirectCase{target: "/a"})
+	if rr.Body.String() != "plain" {
+		t.Errorf("served by %q, want plain", rr.Body.String())
+	}
+	if url, matched := chain.(Matcher).Match(httptest.NewRequest(http.MethodGet, "/a", nil)); url != "" || !matched {
+		t.Errorf("Match() = %q, %v, want an empty url and true", url, matched)
+	}
+}
+
+func TestChainHandlerEmpty(t *testing.T) {
+	chain := ChainHandler()
+	checkRedirects(t, chain, []redirectCase{
+		{name: "not found", target: "/a", status: http.StatusNotFound},
+	})
+	if _, matched := chain.(Matcher).Match(httptest.NewRequest(http.MethodGet, "/a", nil)); matched {
+		t.Error("Match() of an empty chain = true")
+	}
+}
@@ -0,0 +1,71 @@
+package urlshort
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// codeAlphabet is the character set of generated codes: digits,
+// then upper case, then lower case ASCII letters.
+const codeAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// codeLength is the length of the codes picked by Add.
+const codeLength = 7
+
+// maxCodeAttempts is how many codes Add tries before giving up.
+const maxCodeAttempts = 10
+
+// GenerateCode returns a short code for url made of length
+// base62 characters: 0-9, A-Z and a-z. The code is derived from
+// a SHA-256 hash of the url, so the same url and length always
+// give the same code. It returns an empty string if length is
+// not positive.
+func GenerateCode(url string, length int) string {
+	if length <= 0 {
+		return ""
+	}
+	code := make([]byte, 0, length)
+	base := big.NewInt(int64(len(codeAlphabet)))
+	digit := new(big.Int)
+	sum := sha256.Sum256([]byte(url))
+	for len(code) < length {
+		n := new(big.Int).SetBytes(sum[:])
+		// Only use the digits that fit in every hash, then hash
+		// again if more characters are needed.
+		for i := 0; i < 40 && len(code) < length; i++ {
+			n.DivMod(n, base, digit)
+			code = append(code, codeAlphabet[digit.Int64()])
+		}
+		sum = sha256.Sum256(sum[:])
+	}
+	return string(code)
+}
+
+// Add maps a new short path to url and returns that path. The
+// path is "/" followed by a code from GenerateCode. If the code
+// is already used by a different url, other codes are derived
+// from the url until a free one is found. If url already has a
+// generated path, that path is returned again.
+func (mh *MutableHandler) Add(url string) (string, error) {
+	mh.mu.Lock()
+	defer mh.mu.Unlock()
+	for attempt := 0; attempt < maxCodeAttempts; attempt++ {
+		seed := url
+		if attempt > 0 {
+			seed += "#" + strconv.Itoa(attempt)
+		}
+		path := "/" + GenerateCode(seed, codeLength)
+		key := mh.opts.entryKey(path)
+		existing, exists := mh.entries[key]
+		if exists && existing.Url == url {
+			return existing.Path, nil
+		}
+		if !exists {
+			mh.entries[key] = ShortenedUrl{Path: path, Url: url}
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no free code found for url '%s'", url)
+}
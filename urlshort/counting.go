@@ -0,0 +1,56 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// CountingHandler is an http.Handler that behaves like the one
+// returned by MapHandler, and also counts how many times each
+// path was redirected. Requests passed to the fallback are not
+// counted. It is safe for concurrent use.
+type CountingHandler struct {
+	handler *pathRedirector
+	counts  map[string]*atomic.Int64
+}
+
+// NewCountingHandler returns a CountingHandler for the given
+// mappings. If a path is not mapped, then the fallback
+// http.Handler will be called instead.
+func NewCountingHandler(pathsToUrls map[string]string, fallback http.Handler) *CountingHandler {
+	ch := &CountingHandler{
+		counts: make(map[string]*atomic.Int64, len(pathsToUrls)),
+	}
+	opts := Options{OnRedirect: ch.count}
+	handler := newLenientMapHandler(pathsToUrls, opts, fallback)
+	for path := range handler.entries {
+		ch.counts[path] = new(atomic.Int64)
+	}
+	ch.handler = handler
+	return ch
+}
+
+func (ch *CountingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ch.handler.ServeHTTP(w, r)
+}
+
+// Match implements Matcher.
+func (ch *CountingHandler) Match(r *http.Request) (string, bool) {
+	return ch.handler.Match(r)
+}
+
+// Counts returns a snapshot of the number of redirects served
+// for each path in the map, including paths never requested.
+func (ch *CountingHandler) Counts() map[string]int64 {
+	counts := make(map[string]int64, len(ch.counts))
+	for path, count := range ch.counts {
+		counts[path] = count.Load()
+	}
+	return counts
+}
+
+func (ch *CountingHandler) count(r *http.Request, key, url string) {
+	if count, exists := ch.counts[key]; exists {
+		count.Add(1)
+	}
+}
@@ -0,0 +1,278 @@
+package urlshort
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+const defaultDBQuery = "SELECT path, url FROM urls"
+
+// DBHandler will read all redirects from the urls table of the
+// provided database and then return an http.Handler that will
+// attempt to map any paths to their corresponding URL. If the
+// path is not found in the table, then the fallback http.Handler
+// will be called instead.
+//
+// The table is expected to have path and url text columns. Use
+// DBQueryHandler to read from a different table or schema.
+//
+// The table is read once, when the handler is constructed. Rows
+// added later are not visible until a new handler is built.
+func DBHandler(db *sql.DB, fallback http.Handler) (http.Handler, error) {
+	return DBQueryHandler(db, defaultDBQuery, fallback)
+}
+
+// DBHandlerContext works like DBHandler, but reads the table
+// with the given context, so that it can be cancelled or given
+// a deadline.
+func DBHandlerContext(ctx context.Context, db *sql.DB, fallback http.Handler) (http.Handler, error) {
+	return DBQueryHandlerContext(ctx, db, defaultDBQuery, fallback)
+}
+
+// DBQueryHandler works like DBHandler, but reads the redirects
+// using the given query. The query must return exactly two
+// columns, the path and the url, in that order:
+//
+//	SELECT short_path, target FROM links WHERE active
+//
+// The only errors that can be returned are related to running
+// the query, scanning its rows, or a path that appears in more
+// than one row with different urls.
+func DBQueryHandler(db *sql.DB, query string, fallback http.Handler) (http.Handler, error) {
+	return DBQueryHandlerContext(context.Background(), db, query, fallback)
+}
+
+// DBQueryHandlerContext works like DBQueryHandler, but runs the
+// query with the given context, so that it can be cancelled or
+// given a deadline.
+func DBQueryHandlerContext(ctx context.Context, db *sql.DB, query string, fallback http.Handler) (http.Handler, error) {
+	parsedRows, err := queryUrls(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+	return UrlsHandler(parsedRows, Options{}, fallback)
+}
+
+type dbRedirector struct {
+	redirector
+	db    *sql.DB
+	query string
+}
+
+// DBLookupHandler will return an http.Handler that queries the
+// provided database for each request, instead of reading all
+// redirects up front like DBHandler does. The query must take
+// the path as its only argument and return the url as its only
+// column, using the placeholder syntax of the driver:
+//
+//	SELECT url FROM urls WHERE path = ?
+//
+// If the query returns no rows, then the fallback http.Handler
+// will be called instead. The query runs with the context of
+// the request, so it is cancelled when the client goes away or
+// the request deadline passes. Query errors are logged and the
+// request is treated as a miss.
+func DBLookupHandler(db *sql.DB, query string, fallback http.Handler) http.Handler {
+	opts, _ := Options{}.withDefaults()
+	return &dbRedirector{
+		redirector: redirector{opts: opts, fallback: fallback},
+		db:         db,
+		query:      query,
+	}
+}
+
+func (dr *dbRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	dr.serve(w, r, dr.lookupIn(r.Context(), dr, "database"))
+}
+
+// Match implements Matcher.
+func (dr *dbRedirector) Match(r *http.Request) (string, bool) {
+	return dr.matchRequest(r, dr.lookupIn(r.Context(), dr, "database"))
+}
+
+// Lookup implements Store by running the query for path.
+func (dr *dbRedirector) Lookup(ctx context.Context, path string) (string, bool, error) {
+	var url string
+	err := dr.db.QueryRowContext(ctx, dr.query, path).Scan(&url)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return url, true, nil
+}
+
+func queryUrls(ctx context.Context, db *sql.DB, query string) (ShortenedUrls, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls ShortenedUrls
+	for rows.Next() {
+		var url ShortenedUrl
+		if err := rows.Scan(&url.Path, &url.Url); err != nil {
+			slog.Error("Error: " + err.Error())
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// ImportUrls inserts every entry in urls into db, in a single
+// transaction, so that either all of them are added or none
+// are. The statement must take the path and the url as its
+// two arguments, in that order, using the placeholder syntax
+// of the driver:
+//
+//	INSERT INTO urls (path, url) VALUES (?, ?)
+//
+// Each path of an entry, aliases included, gets a row of its
+// own. Disabled entries are skipped, and so a disabled entry
+// never conflicts with an enabled one for the same path. Since
+// a row only holds a url, entries that are gone or pick between
+// weighted targets cannot be imported.
+//
+// The entries are checked before the transaction starts, as
+// handlers built from them would be, with every url required
+// to be absolute, and if any check fails, the *ConfigError
+// listing every problem is returned without touching db. If a
+// statement fails, for example on a unique constraint, the
+// transaction is rolled back and the error is returned. Handlers
+// reading the table all at once, such as SQLHandler, only see
+// the new rows once reloaded.
+func ImportUrls(ctx context.Context, db *sql.DB, statement string, urls ShortenedUrls) error {
+	opts, _ := Options{ValidateURLs: true}.withDefaults()
+	if _, err := opts.buildEntries(urls); err != nil {
+		return err
+	}
+	configErr := &ConfigError{}
+	for i, entry := range urls {
+		if !entry.IsEnabled() {
+			continue
+		}
+		if entry.Gone {
+			configErr.add(i, entry.Path, "gone entries cannot be imported")
+		} else if len(entry.Targets) > 0 {
+			configErr.add(i, entry.Path, "weighted targets cannot be imported")
+		}
+	}
+	if err := configErr.err(); err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insert, err := tx.PrepareContext(ctx, statement)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	inserted := make(map[string]bool, len(urls))
+	for _, entry := range urls {
+		if !entry.IsEnabled() {
+			continue
+		}
+		for _, path := range entryPaths(entry) {
+			// Entries sharing a path point to the same url, or
+			// buildEntries would have failed, so one row is enough.
+			key := opts.entryKey(path)
+			if inserted[key] {
+				continue
+			}
+			inserted[key] = true
+			if _, err := insert.ExecContext(ctx, path, entry.Url); err != nil {
+				return fmt.Errorf("path '%s': %w", path, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// SQLHandler is an http.Handler that serves redirects read from
+// a SQL database, such as Postgres or MySQL. All rows are read
+// into memory, so lookups never touch the database, and Reload
+// reads them again. It is safe for concurrent use.
+//
+// The caller owns the *sql.DB: the handler never closes it, and
+// connection pool settings such as SetMaxOpenConns are left to
+// the caller. Since the handler only queries on construction
+// and on Reload, it needs at most one connection at a time.
+type SQLHandler struct {
+	redirector
+	reloadRecorder
+	db      *sql.DB
+	query   string
+	entries atomic.Pointer[map[string]ShortenedUrl]
+}
+
+// NewSQLHandler runs query against db and returns an SQLHandler
+// serving the rows it returns. The query must return exactly
+// two columns, the path and the url, in that order. If a path
+// is not found, then the fallback http.Handler will be called
+// instead.
+func NewSQLHandler(ctx context.Context, db *sql.DB, query string, fallback http.Handler) (*SQLHandler, error) {
+	opts, _ := Options{}.withDefaults()
+	sh := &SQLHandler{
+		redirector: redirector{opts: opts, fallback: fallback},
+		db:         db,
+		query:      query,
+	}
+	if err := sh.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return sh, nil
+}
+
+// Reload runs the query again and replaces the redirects being
+// served with its rows, all at once. If the query fails, the
+// previous redirects keep being served. Either way, the attempt
+// is counted in ReloadStats.
+func (sh *SQLHandler) Reload(ctx context.Context) error {
+	return sh.recordReload(sh.load(ctx))
+}
+
+func (sh *SQLHandler) load(ctx context.Context) error {
+	urls, err := queryUrls(ctx, sh.db, sh.query)
+	if err != nil {
+		return err
+	}
+	entries, err := sh.opts.buildEntries(urls)
+	if err != nil {
+		return err
+	}
+	sh.entries.Store(&entries)
+	return nil
+}
+
+func (sh *SQLHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sh.serve(w, r, sh.lookup)
+}
+
+// Match implements Matcher.
+func (sh *SQLHandler) Match(r *http.Request) (string, bool) {
+	return sh.matchRequest(r, sh.lookup)
+}
+
+func (sh *SQLHandler) lookup(key string) (ShortenedUrl, bool) {
+	entry, exists := (*sh.entries.Load())[key]
+	return entry, exists
+}
@@ -0,0 +1,334 @@
+package urlshort
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// fakeDB is a database/sql driver holding a single table of
+// paths and urls in memory. Statements are told apart by their
+// number of arguments: two insert a row, one looks a path up
+// and none read every row, whatever their text.
+type fakeDB struct {
+	mu   sync.Mutex
+	rows map[string]string
+	// fail, if set, is returned by every statement.
+	fail error
+}
+
+// openFakeDB returns a *sql.DB backed by a fakeDB holding rows,
+// closed when the test ends.
+func openFakeDB(t *testing.T, rows map[string]string) (*sql.DB, *fakeDB) {
+	t.Helper()
+	fake := &fakeDB{rows: map[string]string{}}
+	for path, url := range rows {
+		fake.rows[path] = url
+	}
+	db := sql.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+	return db, fake
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+// table returns a copy of the rows of f.
+func (f *fakeDB) table() map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := make(map[string]string, len(f.rows))
+	for path, url := range f.rows {
+		rows[path] = url
+	}
+	return rows
+}
+
+// fakeConn is a connection to a fakeDB. Writes made in a
+// transaction are kept aside until it commits.
+type fakeConn struct {
+	db      *fakeDB
+	pending map[string]string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{conn: c}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.pending = map[string]string{}
+	return c, nil
+}
+
+// Commit implements driver.Tx.
+func (c *fakeConn) Commit() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for path, url := range c.pending {
+		c.db.rows[path] = url
+	}
+	c.pending = nil
+	return nil
+}
+
+// Rollback implements driver.Tx.
+func (c *fakeConn) Rollback() error {
+	c.pending = nil
+	return nil
+}
+
+type fakeStmt struct {
+	conn *fakeConn
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db := s.conn.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.fail != nil {
+		return nil, db.fail
+	}
+	if len(args) != 2 {
+		return nil, errors.New("fake: insert takes a path and a url")
+	}
+	path, url := args[0].(string), args[1].(string)
+	_, stored := db.rows[path]
+	_, pending := s.conn.pending[path]
+	if stored || pending {
+		return nil, errors.New("fake: duplicate path " + path)
+	}
+	if s.conn.pending != nil {
+		s.conn.pending[path] = url
+	} else {
+		db.rows[path] = url
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	db := s.conn.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.fail != nil {
+		return nil, db.fail
+	}
+	rows := &fakeRows{}
+	switch len(args) {
+	case 0:
+		for path, url := range db.rows {
+			rows.values = append(rows.values, []driver.Value{path, url})
+		}
+		sort.Slice(rows.values, func(i, j int) bool {
+			return rows.values[i][0].(string) < rows.values[j][0].(string)
+		})
+		rows.columns = []string{"path", "url"}
+	case 1:
+		if url, exists := db.rows[args[0].(string)]; exists {
+			rows.values = append(rows.values, []driver.Value{url})
+		}
+		rows.columns = []string{"url"}
+	default:
+		return nil, errors.New("fake: unexpected query")
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// set maps path to url in f, or removes it if url is empty.
+func (f *fakeDB) set(path, url string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if url == "" {
+		delete(f.rows, path)
+		return
+	}
+	f.rows[path] = url
+}
+
+// failWith makes every later statement fail with err, or
+// succeed again if err is nil.
+func (f *fakeDB) failWith(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = err
+}
+
+func TestDBHandler(t *testing.T) {
+	db, _ := openFakeDB(t, map[string]string{
+		"/a":    "https://example.com/a",
+		"/%62":  "https://example.com/b",
+		"/docs": "https://example.com/docs",
+	})
+	handler, err := DBHandler(db, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "encoded row", target: "/b", status: http.StatusMovedPermanently, location: "https://example.com/b"},
+		{name: "miss", target: "/c", status: http.StatusNotFound},
+	})
+}
+
+func TestDBHandlerErrors(t *testing.T) {
+	db, fake := openFakeDB(t, nil)
+	fake.failWith(errors.New("fake: down"))
+	if _, err := DBHandler(db, http.NotFoundHandler()); err == nil {
+		t.Error("DBHandler(failing query) = nil error")
+	}
+
+	// Rows that decode to the same path must not disagree.
+	db, _ = openFakeDB(t, map[string]string{
+		"/a":   "https://example.com/1",
+		"/%61": "https://example.com/2",
+	})
+	if _, err := DBQueryHandler(db, "SELECT", http.NotFoundHandler()); err == nil {
+		t.Error("DBQueryHandler(duplicate path) = nil error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := DBHandlerContext(ctx, db, http.NotFoundHandler()); err == nil {
+		t.Error("DBHandlerContext(cancelled) = nil error")
+	}
+}
+
+func TestSQLHandlerReload(t *testing.T) {
+	db, fake := openFakeDB(t, map[string]string{"/a": "https://example.com/a"})
+	handler, err := NewSQLHandler(context.Background(), db, "SELECT", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "initial", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "not yet added", target: "/b", status: http.StatusNotFound},
+	})
+
+	// Rows added later are only served once reloaded.
+	fake.set("/b", "https://example.com/b")
+	fake.set("/a", "")
+	checkRedirects(t, handler, []redirectCase{
+		{name: "before reload", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+	})
+	if err := handler.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "removed", target: "/a", status: http.StatusNotFound},
+		{name: "added", target: "/b", status: http.StatusMovedPermanently, location: "https://example.com/b"},
+	})
+
+	// A failed reload keeps the previous rows.
+	fake.failWith(errors.New("fake: down"))
+	if err := handler.Reload(context.Background()); err == nil {
+		t.Error("Reload(failing query) = nil error")
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "kept", target: "/b", status: http.StatusMovedPermanently, location: "https://example.com/b"},
+	})
+	if stats := handler.ReloadStats(); stats.Successes != 2 || stats.Failures != 1 {
+		t.Errorf("ReloadStats() = %+v, want 2 successes and 1 failure", stats)
+	}
+}
+
+func TestImportUrls(t *testing.T) {
+	db, fake := openFakeDB(t, nil)
+	disabled := false
+	err := ImportUrls(context.Background(), db, "INSERT", ShortenedUrls{
+		{Path: "/a", Url: "https://example.com/old", Enabled: &disabled},
+		{Path: "/a", Url: "https://example.com/a"},
+		{Path: "/gh", Paths: []string{"/github"}, Url: "https://github.com"},
+		{Path: "/github", Url: "https://github.com"},
+		{Path: "/off", Gone: true, Enabled: &disabled},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"/a":      "https://example.com/a",
+		"/gh":     "https://github.com",
+		"/github": "https://github.com",
+	}
+	if got := fake.table(); !equalRows(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+}
+
+func TestImportUrlsRejected(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		urls ShortenedUrls
+	}{
+		{name: "gone", urls: ShortenedUrls{{Path: "/old", Gone: true}}},
+		{name: "targets", urls: ShortenedUrls{{Path: "/w", Targets: []Target{{Url: "https://example.com", Weight: 1}}}}},
+		{name: "relative url", urls: ShortenedUrls{{Path: "/a", Url: "example.com"}}},
+		{name: "duplicate path", urls: ShortenedUrls{
+			{Path: "/a", Url: "https://example.com/1"},
+			{Path: "/a", Url: "https://example.com/2"},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db, fake := openFakeDB(t, nil)
+			err := ImportUrls(context.Background(), db, "INSERT", append(ShortenedUrls{
+				{Path: "/ok", Url: "https://example.com/ok"},
+			}, tc.urls...))
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("ImportUrls() = %v, want a *ConfigError", err)
+			}
+			if rows := fake.table(); len(rows) != 0 {
+				t.Errorf("rows = %v, want none", rows)
+			}
+		})
+	}
+}
+
+func TestImportUrlsRollback(t *testing.T) {
+	db, fake := openFakeDB(t, map[string]string{"/b": "https://example.com/taken"})
+	err := ImportUrls(context.Background(), db, "INSERT", ShortenedUrls{
+		{Path: "/a", Url: "https://example.com/a"},
+		{Path: "/b", Url: "https://example.com/b"},
+	})
+	if err == nil {
+		t.Fatal("ImportUrls() = nil, want the insert error")
+	}
+	want := map[string]string{"/b": "https://example.com/taken"}
+	if got := fake.table(); !equalRows(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+}
+
+func equalRows(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, url := range a {
+		if b[path] != url {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package urlshort
+
+import "net/http"
+
+// The headers the Debug option sets.
+const (
+	debugMatchHeader  = "X-Shortlink-Match"
+	debugSourceHeader = "X-Shortlink-Source"
+	debugMissHeader   = "X-Shortlink-Miss"
+)
+
+// setSource records name as the config file every entry in urls
+// was read from.
+func setSource(urls ShortenedUrls, name string) {
+	for i := range urls {
+		urls[i].source = name
+	}
+}
+
+// debugMatch sets the headers telling which entry r matched, if
+// the options ask for them.
+func (rd *redirector) debugMatch(w http.ResponseWriter, key string, entry ShortenedUrl) {
+	if !rd.opts.Debug {
+		return
+	}
+	w.Header().Set(debugMatchHeader, key)
+	if entry.source != "" {
+		w.Header().Set(debugSourceHeader, entry.source)
+	}
+}
+
+// debugMiss sets the header telling that r matched no entry, if
+// the options ask for it.
+func (rd *redirector) debugMiss(w http.ResponseWriter) {
+	if rd.opts.Debug {
+		w.Header().Set(debugMissHeader, "true")
+	}
+}
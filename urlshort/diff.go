@@ -0,0 +1,80 @@
+package urlshort
+
+import "sort"
+
+// ConfigDiff describes how one set of redirects differs from
+// another, as returned by DiffConfigs. Each slice is sorted by
+// path.
+type ConfigDiff struct {
+	// Added lists the entries whose path is only in the new set.
+	Added ShortenedUrls `json:"added"`
+	// Removed lists the entries whose path is only in the old
+	// set.
+	Removed ShortenedUrls `json:"removed"`
+	// Changed lists the paths in both sets that redirect to a
+	// different place in each.
+	Changed []ConfigChange `json:"changed"`
+}
+
+// ConfigChange is a path whose entry differs between the old
+// and the new set of a ConfigDiff.
+type ConfigChange struct {
+	Old ShortenedUrl `json:"old"`
+	New ShortenedUrl `json:"new"`
+}
+
+// DiffConfigs compares oldUrls with newUrls by path and returns
+// the paths added, removed and retargeted, for example to
+// describe the change to a config under review. An entry is
+// retargeted when its url, targets or gone marker change; a
+// change to other fields alone, such as Description, is not
+// reported. Each alias in Paths is compared as a path of its
+// own, and paths are compared once decoded, as the handlers do
+// with default options. When a path appears more than once in
+// a set, the last entry wins, as with BuildMap.
+func DiffConfigs(oldUrls, newUrls ShortenedUrls) ConfigDiff {
+	oldEntries := lastByPath(oldUrls)
+	newEntries := lastByPath(newUrls)
+
+	var diff ConfigDiff
+	for path, newEntry := range newEntries {
+		oldEntry, exists := oldEntries[path]
+		if !exists {
+			diff.Added = append(diff.Added, newEntry)
+		} else if !sameDestination(oldEntry, newEntry) {
+			diff.Changed = append(diff.Changed, ConfigChange{Old: oldEntry, New: newEntry})
+		}
+	}
+	for path, oldEntry := range oldEntries {
+		if _, exists := newEntries[path]; !exists {
+			diff.Removed = append(diff.Removed, oldEntry)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool {
+		return diff.Added[i].Path < diff.Added[j].Path
+	})
+	sort.Slice(diff.Removed, func(i, j int) bool {
+		return diff.Removed[i].Path < diff.Removed[j].Path
+	})
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].New.Path < diff.Changed[j].New.Path
+	})
+	return diff
+}
+
+// lastByPath maps the key of each path in urls, aliases
+// included, to the last entry with it, whose Path is set to
+// that path.
+func lastByPath(urls ShortenedUrls) map[string]ShortenedUrl {
+	opts, _ := Options{}.withDefaults()
+	entries := make(map[string]ShortenedUrl, len(urls))
+	for _, entry := range urls {
+		for _, path := range entryPaths(entry) {
+			entry := entry
+			entry.Path = path
+			entries[opts.entryKey(path)] = entry
+		}
+	}
+	return entries
+}
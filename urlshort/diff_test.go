@@ -0,0 +1,88 @@
+package urlshort
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffConfigsAliases(t *testing.T) {
+	diff := DiffConfigs(
+		ShortenedUrls{
+			{Paths: []string{"/gh", "/github"}, Url: "https://github.com"},
+		},
+		ShortenedUrls{
+			{Path: "/gh", Url: "https://github.com"},
+			{Path: "/git%68ub", Url: "https://github.com/new"},
+			{Paths: []string{"/docs"}, Url: "https://example.com/docs"},
+		},
+	)
+	if len(diff.Removed) != 0 {
+		t.Errorf("Removed = %+v, want none", diff.Removed)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Path != "/docs" {
+		t.Errorf("Added = %+v, want /docs", diff.Added)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].Old.Path != "/github" || diff.Changed[0].New.Url != "https://github.com/new" {
+		t.Errorf("Changed = %+v, want /github", diff.Changed)
+	}
+}
+
+func TestDiffConfigs(t *testing.T) {
+	diff := DiffConfigs(
+		ShortenedUrls{
+			{Path: "/same", Url: "https://example.com/same", Description: "old"},
+			{Path: "/moved", Url: "https://example.com/before"},
+			{Path: "/retired", Url: "https://example.com/retired"},
+			{Path: "/removed", Url: "https://example.com/removed"},
+			{Path: "/weighted", Targets: []Target{{Url: "https://a.example.com", Weight: 1}}},
+			{Path: "/twice", Url: "https://example.com/first"},
+			{Path: "/twice", Url: "https://example.com/last"},
+		},
+		ShortenedUrls{
+			{Path: "/weighted", Targets: []Target{{Url: "https://a.example.com", Weight: 2}}},
+			{Path: "/same", Url: "https://example.com/same", Description: "new"},
+			{Path: "/moved", Url: "https://example.com/after"},
+			{Path: "/retired", Gone: true},
+			{Path: "/twice", Url: "https://example.com/last"},
+			{Path: "/b-added", Url: "https://example.com/b"},
+			{Path: "/a-added", Url: "https://example.com/a"},
+		},
+	)
+	paths := func(urls ShortenedUrls) []string {
+		var paths []string
+		for _, url := range urls {
+			paths = append(paths, url.Path)
+		}
+		return paths
+	}
+	if got, want := paths(diff.Added), []string{"/a-added", "/b-added"}; !slices.Equal(got, want) {
+		t.Errorf("Added = %v, want %v", got, want)
+	}
+	if got, want := paths(diff.Removed), []string{"/removed"}; !slices.Equal(got, want) {
+		t.Errorf("Removed = %v, want %v", got, want)
+	}
+	var changed []string
+	for _, change := range diff.Changed {
+		if change.Old.Path != change.New.Path {
+			t.Errorf("change of %s has new path %s", change.Old.Path, change.New.Path)
+		}
+		changed = append(changed, change.New.Path)
+	}
+	if want := []string{"/moved", "/retired", "/weighted"}; !slices.Equal(changed, want) {
+		t.Errorf("Changed = %v, want %v", changed, want)
+	}
+}
+
+func TestDiffConfigsEmpty(t *testing.T) {
+	urls := ShortenedUrls{{Path: "/a", Url: "https://example.com/a"}}
+	diff := DiffConfigs(urls, urls)
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) != 0 {
+		t.Errorf("DiffConfigs(same) = %+v, want no differences", diff)
+	}
+	if diff := DiffConfigs(nil, urls); len(diff.Added) != 1 {
+		t.Errorf("DiffConfigs(nil, urls).Added = %+v, want /a", diff.Added)
+	}
+	if diff := DiffConfigs(urls, nil); len(diff.Removed) != 1 {
+		t.Errorf("DiffConfigs(urls, nil).Removed = %+v, want /a", diff.Removed)
+	}
+}
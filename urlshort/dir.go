@@ -0,0 +1,151 @@
+package urlshort
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirHandler reads every .yaml, .yml and .json file directly in
+// dir, parsing each by its extension, and returns a handler
+// serving all their mappings together. Files are merged in
+// lexical order of their names, and other files and
+// subdirectories are ignored.
+// If a path is not mapped, then the fallback http.Handler will
+// be called instead.
+//
+// A path mapped to two different urls, whether in one file or
+// in two, is an error. In the *ConfigError returned, each
+// problem has the file of the entry read last as its Source,
+// and the position of the entry in that file as its Index, and
+// its message names the file the other url came from.
+func DirHandler(dir string, fallback http.Handler) (http.Handler, error) {
+	return DirHandlerMode(dir, MergeError, fallback)
+}
+
+// DirHandlerMode works like DirHandler, but handles a path
+// mapped to two different urls according to mode. With
+// MergeWarn and MergeSilent, the entry read last wins, so a
+// file overrides those before it in lexical order, and with
+// MergeWarn each override is logged along with both files.
+func DirHandlerMode(dir string, mode MergeMode, fallback http.Handler) (http.Handler, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	opts, _ := Options{}.withDefaults()
+	var urls ShortenedUrls
+	var origins []dirOrigin
+	for _, file := range files {
+		if file.IsDir() || !isDirConfig(file.Name()) {
+			continue
+		}
+		name := filepath.Join(dir, file.Name())
+		parsed, err := parseFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("file '%s': %w", name, err)
+		}
+		urls = append(urls, parsed...)
+		for i := range parsed {
+			origins = append(origins, dirOrigin{file: name, index: i})
+		}
+	}
+	urls, err = mergeDirUrls(opts, urls, origins, mode)
+	if err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+	return UrlsHandler(urls, Options{}, fallback)
+}
+
+// isDirConfig reports whether DirHandler reads the file name.
+func isDirConfig(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// parseFile reads the config file name, parsing it by its
+// extension, and records it as the source of every entry.
+func parseFile(name string) (ShortenedUrls, error) {
+	parse, err := parserForFile(name)
+	if err != nil {
+		return nil, err
+	}
+	input, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	urls, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	setSource(urls, name)
+	return urls, nil
+}
+
+// dirOrigin is where DirHandler read an entry: the file, and
+// the position of the entry in it.
+type dirOrigin struct {
+	file  string
+	index int
+}
+
+// mergeDirUrls handles every path mapped to two different
+// urls according to mode, naming the file each came from, as
+// origins holds where every entry in urls was read. With
+// MergeError, urls are returned as they are if there is no
+// conflict. Otherwise, the entries are returned one per path,
+// each being the last one read for that path.
+func mergeDirUrls(opts Options, urls ShortenedUrls, origins []dirOrigin, mode MergeMode) (ShortenedUrls, error) {
+	type seen struct {
+		entry ShortenedUrl
+		file  string
+		index int
+	}
+	configErr := &ConfigError{}
+	entries := map[string]seen{}
+	var merged ShortenedUrls
+	for i, entry := range urls {
+		if !entry.IsEnabled() {
+			continue
+		}
+		for _, path := range entryPaths(entry) {
+			entry := entry
+			entry.Path, entry.Paths = path, nil
+			key := opts.entryKey(path)
+			existing, exists := entries[key]
+			if !exists {
+				entries[key] = seen{entry: entry, file: origins[i].file, index: len(merged)}
+				merged = append(merged, entry)
+				continue
+			}
+			if !sameDestination(existing.entry, entry) {
+				switch mode {
+				case MergeError:
+					configErr.addIn(origins[i].file, origins[i].index, path, "'%s' conflicts with '%s' in '%s'",
+						entry.Url, existing.entry.Url, existing.file)
+					continue
+				case MergeWarn:
+					slog.Warn("Overriding path while merging",
+						slog.String("path", path),
+						slog.String("url", entry.Url),
+						slog.String("file", origins[i].file),
+						slog.String("previous", existing.entry.Url),
+						slog.String("previous_file", existing.file))
+				}
+			}
+			entries[key] = seen{entry: entry, file: origins[i].file, index: existing.index}
+			merged[existing.index] = entry
+		}
+	}
+	if mode == MergeError {
+		return urls, configErr.err()
+	}
+	return merged, nil
+}
@@ -0,0 +1,89 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeDir writes each file in files to a new temporary
+// directory, creating subdirectories as needed, and returns it.
+func writeDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDirHandler(t *testing.T) {
+	dir := writeDir(t, map[string]string{
+		"a.yaml":      "- path: /a\n  url: https://example.com/a\n",
+		"b.json":      `[{"path": "/b", "url": "https://example.com/b"}, {"path": "/a", "url": "https://example.com/a"}]`,
+		"c.YML":       "- path: /c\n  url: https://example.com/c\n",
+		"notes.txt":   "/d = https://example.com/d\n",
+		"sub/d.yaml":  "- path: /d\n  url: https://example.com/d\n",
+		"broken.toml": "not toml at all = = =",
+	})
+	handler, err := DirHandler(dir, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "yaml", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "json", target: "/b", status: http.StatusMovedPermanently, location: "https://example.com/b"},
+		{name: "extension in any case", target: "/c", status: http.StatusMovedPermanently, location: "https://example.com/c"},
+		{name: "other files and subdirectories ignored", target: "/d", status: http.StatusNotFound},
+	})
+}
+
+func TestDirHandlerConflict(t *testing.T) {
+	dir := writeDir(t, map[string]string{
+		"a.yaml": "- path: /a\n  url: https://example.com/first\n",
+		"b.yaml": "- path: /b\n  url: https://example.com/b\n- path: /a\n  url: https://example.com/second\n",
+	})
+	_, err := DirHandler(dir, http.NotFoundHandler())
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || len(configErr.Problems) != 1 {
+		t.Fatalf("DirHandler() = %v, want one conflict", err)
+	}
+	problem := configErr.Problems[0]
+	if problem.Source != filepath.Join(dir, "b.yaml") || problem.Index != 1 || problem.Path != "/a" {
+		t.Errorf("problem = %+v, want /a at index 1 of b.yaml", problem)
+	}
+	if !strings.Contains(problem.Message, filepath.Join(dir, "a.yaml")) {
+		t.Errorf("message %q does not name a.yaml", problem.Message)
+	}
+
+	for _, mode := range []MergeMode{MergeWarn, MergeSilent} {
+		handler, err := DirHandlerMode(dir, mode, http.NotFoundHandler())
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRedirects(t, handler, []redirectCase{
+			{name: "last file wins", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/second"},
+			{name: "kept", target: "/b", status: http.StatusMovedPermanently, location: "https://example.com/b"},
+		})
+	}
+}
+
+func TestDirHandlerErrors(t *testing.T) {
+	if _, err := DirHandler(filepath.Join(t.TempDir(), "missing"), http.NotFoundHandler()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("DirHandler(missing) = %v, want os.ErrNotExist", err)
+	}
+	dir := writeDir(t, map[string]string{"bad.json": "[{"})
+	_, err := DirHandler(dir, http.NotFoundHandler())
+	if err == nil || !strings.Contains(err.Error(), "bad.json") {
+		t.Errorf("DirHandler(malformed) = %v, want an error naming bad.json", err)
+	}
+}
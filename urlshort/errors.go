@@ -0,0 +1,95 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupportedFormat is wrapped by the errors returned for a
+// config format, or file extension, that this package cannot
+// read, so that callers can tell it apart from invalid content
+// with errors.Is. The message names the format or file.
+var ErrUnsupportedFormat = errors.New("unsupported config format")
+
+// ErrInputTooLarge is wrapped by the errors returned when
+// gzipped config input expands past the size this package is
+// willing to decompress.
+var ErrInputTooLarge = errors.New("config input too large")
+
+// ConfigError is returned when a set of redirects fails to
+// build or validate. It carries every problem found, rather
+// than only the first, so that callers can report them all at
+// once:
+//
+//	var configErr *urlshort.ConfigError
+//	if errors.As(err, &configErr) {
+//		for _, problem := range configErr.Problems {
+//			fmt.Println(problem.Path, problem.Message)
+//		}
+//	}
+type ConfigError struct {
+	Problems []ConfigProblem
+}
+
+// ConfigProblem is a single problem with one entry of a set of
+// redirects.
+type ConfigProblem struct {
+	// Index is the position of the entry in the set, or in the
+	// file named by Source if it is set.
+	Index int
+	// Source is the config file the entry was read from, for
+	// sets merged from several files, such as by DirHandler. It
+	// is empty otherwise.
+	Source string
+	// Path is the path of the entry, which may be empty if the
+	// problem is that the path is missing.
+	Path string
+	// Message describes the problem.
+	Message string
+}
+
+// Error returns one line per problem.
+func (e *ConfigError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		lines[i] = problem.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p ConfigProblem) String() string {
+	var prefix string
+	if p.Source != "" {
+		prefix = fmt.Sprintf("file '%s': ", p.Source)
+	}
+	if p.Path == "" {
+		return fmt.Sprintf("%sentry %d: %s", prefix, p.Index, p.Message)
+	}
+	return fmt.Sprintf("%spath '%s': %s", prefix, p.Path, p.Message)
+}
+
+// add records a problem with the entry at index.
+func (e *ConfigError) add(index int, path, format string, args ...any) {
+	e.Problems = append(e.Problems, ConfigProblem{
+		Index:   index,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// addIn records a problem with the entry at index in the
+// config file source.
+func (e *ConfigError) addIn(source string, index int, path, format string, args ...any) {
+	e.add(index, path, format, args...)
+	e.Problems[len(e.Problems)-1].Source = source
+}
+
+// err returns e, or nil if no problems were recorded, so that a
+// nil *ConfigError never ends up in a non-nil error.
+func (e *ConfigError) err() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,40 @@
+package urlshort
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportYAML returns the mappings of pathsToUrls as a YAML list
+// of ShortenedUrl, sorted by path, in the format YAMLHandler
+// reads. The output only depends on the mappings, so exporting
+// the same map twice gives the same bytes, which keeps backups
+// easy to diff.
+func ExportYAML(pathsToUrls map[string]string) ([]byte, error) {
+	return yaml.Marshal(urlsFromMap(pathsToUrls))
+}
+
+// ExportUrlsYAML works like ExportYAML, but exports the given
+// entries, sorted by path, so that fields such as Status are
+// kept.
+func ExportUrlsYAML(urls ShortenedUrls) ([]byte, error) {
+	return yaml.Marshal(sortedByPath(urls))
+}
+
+// ExportJSON works like ExportYAML, but returns an indented JSON
+// array in the format JSONHandler reads.
+func ExportJSON(pathsToUrls map[string]string) ([]byte, error) {
+	return ExportUrlsJSON(urlsFromMap(pathsToUrls))
+}
+
+// ExportUrlsJSON works like ExportJSON, but exports the given
+// entries, sorted by path, so that fields such as Status are
+// kept.
+func ExportUrlsJSON(urls ShortenedUrls) ([]byte, error) {
+	out, err := json.MarshalIndent(sortedByPath(urls), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
@@ -0,0 +1,88 @@
+package urlshort
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportRoundTrip(t *testing.T) {
+	disabled := false
+	urls := ShortenedUrls{
+		{Path: "/b", Url: "https://example.com/b", Status: http.StatusFound, Paths: []string{"/bee"}},
+		{Path: "/a", Url: "https://example.com/a", Description: "first", Methods: []string{http.MethodGet}},
+		{Path: "/gone", Gone: true},
+		{Path: "/w", Targets: []Target{{Url: "https://a.example.com", Weight: 2}, {Url: "https://b.example.com", Weight: 1}}},
+		{Path: "/off", Url: "https://example.com/off", Enabled: &disabled, MaxHits: 3},
+	}
+	sorted := ShortenedUrls{urls[1], urls[0], urls[2], urls[4], urls[3]}
+	for _, tc := range []struct {
+		name   string
+		export func(ShortenedUrls) ([]byte, error)
+		parse  func([]byte) (ShortenedUrls, error)
+	}{
+		{name: "yaml", export: ExportUrlsYAML, parse: ParseYAML},
+		{name: "json", export: ExportUrlsJSON, parse: ParseJSON},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := tc.export(urls)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := tc.parse(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(parsed, sorted) {
+				t.Errorf("round trip = %+v, want %+v", parsed, sorted)
+			}
+			again, err := tc.export(parsed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, again) {
+				t.Errorf("exporting twice differs:\n%s\n%s", out, again)
+			}
+		})
+	}
+}
+
+func TestExportMap(t *testing.T) {
+	pathsToUrls := map[string]string{
+		"/b": "https://example.com/b",
+		"/a": "https://example.com/a",
+	}
+	yamlOut, err := ExportYAML(pathsToUrls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonOut, err := ExportJSON(pathsToUrls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(jsonOut), "\n") {
+		t.Error("ExportJSON() output does not end with a newline")
+	}
+	if strings.Index(string(yamlOut), "/a") > strings.Index(string(yamlOut), "/b") {
+		t.Errorf("ExportYAML() not sorted by path:\n%s", yamlOut)
+	}
+	for _, tc := range []struct {
+		name    string
+		out     []byte
+		handler func([]byte, http.Handler) (http.Handler, error)
+	}{
+		{name: "yaml", out: yamlOut, handler: YAMLHandler},
+		{name: "json", out: jsonOut, handler: JSONHandler},
+	} {
+		handler, err := tc.handler(tc.out, http.NotFoundHandler())
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		checkRedirects(t, handler, []redirectCase{
+			{name: tc.name + " a", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+			{name: tc.name + " b", target: "/b", status: http.StatusMovedPermanently, location: "https://example.com/b"},
+		})
+	}
+}
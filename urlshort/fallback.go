@@ -0,0 +1,179 @@
+package urlshort
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FallbackRouter is an http.Handler that picks a fallback
+// handler based on the request path. Pass it as the fallback of
+// any handler in this package to serve misses differently, for
+// example with a JSON 404 under /api/* and an HTML one
+// elsewhere.
+//
+// Routes must all be added with Handle before the router starts
+// serving requests.
+type FallbackRouter struct {
+	exact    map[string]http.Handler
+	prefixes []fallbackPrefix
+	fallback http.Handler
+}
+
+type fallbackPrefix struct {
+	prefix  string
+	handler http.Handler
+}
+
+// NewFallbackRouter returns a FallbackRouter that calls the
+// fallback http.Handler for paths matching no route.
+func NewFallbackRouter(fallback http.Handler) *FallbackRouter {
+	return &FallbackRouter{
+		exact:    map[string]http.Handler{},
+		fallback: fallback,
+	}
+}
+
+// Handle routes requests matching pattern to handler. Like the
+// keys of PrefixHandler, a pattern ending in "/*" matches every
+// path under it, and any other pattern matches only that exact
+// path. Exact patterns take priority over prefixes, and longer
+// prefixes over shorter ones. It returns fr so calls can be
+// chained.
+func (fr *FallbackRouter) Handle(pattern string, handler http.Handler) *FallbackRouter {
+	if prefix, isPrefix := strings.CutSuffix(pattern, "*"); isPrefix && strings.HasSuffix(prefix, "/") {
+		fr.prefixes = append(fr.prefixes, fallbackPrefix{prefix: prefix, handler: handler})
+		sort.SliceStable(fr.prefixes, func(i, j int) bool {
+			return len(fr.prefixes[i].prefix) > len(fr.prefixes[j].prefix)
+		})
+		return fr
+	}
+	fr.exact[pattern] = handler
+	return fr
+}
+
+func (fr *FallbackRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fr.route(r.URL.Path).ServeHTTP(w, r)
+}
+
+func (fr *FallbackRouter) route(path string) http.Handler {
+	if handler, exists := fr.exact[path]; exists {
+		return handler
+	}
+	for _, p := range fr.prefixes {
+		if strings.HasPrefix(path, p.prefix) {
+			return p.handler
+		}
+	}
+	return fr.fallback
+}
+
+// notFoundText returns a handler responding with a 404 and body
+// as plain text.
+func notFoundText(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, body)
+	})
+}
+
+// NotFoundHandler returns an http.Handler responding with a 404
+// in the format the client asks for in its Accept header:
+// {"error":"not found"} to clients preferring JSON, and plain
+// text to everyone else, browsers included. It is meant as the
+// fallback of handlers serving API clients.
+func NotFoundHandler() http.Handler {
+	text := notFoundText("404 page not found\n")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !prefersJSON(r.Header.Values("Accept")) {
+			text.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"error":"not found"}`+"\n")
+	})
+}
+
+// prefersJSON reports whether the Accept header values rank a
+// JSON media type at least as high as any text one. Wildcards
+// such as */* do not count as asking for JSON.
+func prefersJSON(accept []string) bool {
+	var jsonQ, textQ float64
+	for _, value := range accept {
+		for _, part := range strings.Split(value, ",") {
+			mediaType, params, err := mime.ParseMediaType(part)
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if raw, exists := params["q"]; exists {
+				if q, err = strconv.ParseFloat(raw, 64); err != nil {
+					continue
+				}
+			}
+			switch {
+			case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+				jsonQ = max(jsonQ, q)
+			case strings.HasPrefix(mediaType, "text/"):
+				textQ = max(textQ, q)
+			}
+		}
+	}
+	return jsonQ > 0 && jsonQ >= textQ
+}
+
+// WeightedFallback is one of the handlers a
+// WeightedFallbackHandler spreads requests over.
+type WeightedFallback struct {
+	Handler http.Handler
+	Weight  float64
+}
+
+// WeightedFallbackHandler returns an http.Handler passing each
+// request to one of fallbacks, picked at random in proportion
+// to its Weight, for example to move misses from one legacy
+// backend to another a share at a time. A zero weight stops a
+// handler from getting requests, unless every weight is zero,
+// in which case requests are spread evenly. It is meant as the
+// fallback of the other handlers in this package.
+//
+// random returns a number in [0, 1) and is never called
+// concurrently, so tests can pass the Float64 method of a
+// seeded *rand.Rand. When nil, math/rand is used.
+//
+// An error is returned if fallbacks is empty, or if any handler
+// is nil or any weight is negative.
+func WeightedFallbackHandler(fallbacks []WeightedFallback, random func() float64) (http.Handler, error) {
+	if len(fallbacks) == 0 {
+		return nil, fmt.Errorf("no fallback handlers")
+	}
+	for i, fallback := range fallbacks {
+		if fallback.Handler == nil {
+			return nil, fmt.Errorf("fallback %d: nil handler", i)
+		}
+		if fallback.Weight < 0 {
+			return nil, fmt.Errorf("fallback %d: negative weight %g", i, fallback.Weight)
+		}
+	}
+	fallbacks = slices.Clone(fallbacks)
+	pick := rand.Float64
+	if random != nil {
+		pick = lockedRandom(random)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := pickWeighted(len(fallbacks), func(i int) float64 {
+			return fallbacks[i].Weight
+		}, pick)
+		fallbacks[i].Handler.ServeHTTP(w, r)
+	}), nil
+}
@@ -0,0 +1,49 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestWeightedFallbackHandler(t *testing.T) {
+	backend := func(url string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, url+r.URL.Path, http.StatusFound)
+		})
+	}
+	values := []float64{0.1, 0.9, 0.5}
+	random := func() float64 {
+		v := values[0]
+		values = append(values[1:], v)
+		return v
+	}
+	fallback, err := WeightedFallbackHandler([]WeightedFallback{
+		{Handler: backend("https://old.example.com"), Weight: 3},
+		{Handler: backend("https://new.example.com"), Weight: 1},
+		{Handler: backend("https://never.example.com"), Weight: 0},
+	}, random)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := MapHandler(map[string]string{"/a": "https://example.com/a"}, fallback)
+
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "first share", target: "/x", status: http.StatusFound, location: "https://old.example.com/x"},
+		{name: "second share", target: "/y", status: http.StatusFound, location: "https://new.example.com/y"},
+		{name: "first share again", target: "/z", status: http.StatusFound, location: "https://old.example.com/z"},
+	})
+}
+
+func TestWeightedFallbackHandlerErrors(t *testing.T) {
+	tests := map[string][]WeightedFallback{
+		"empty":           nil,
+		"nil handler":     {{Weight: 1}},
+		"negative weight": {{Handler: http.NotFoundHandler(), Weight: -1}},
+	}
+	for name, fallbacks := range tests {
+		if _, err := WeightedFallbackHandler(fallbacks, nil); err == nil {
+			t.Errorf("%s: got no error", name)
+		}
+	}
+}
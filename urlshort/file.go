@@ -0,0 +1,197 @@
+package urlshort
+
+import (
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay is how long a watched file must go without
+// changes before it is reloaded.
+const reloadDelay = 100 * time.Millisecond
+
+// FileHandler is an http.Handler that serves the mappings from
+// a config file, and reloads them whenever the file changes.
+// ReloadStats tells how the reloads went. It is safe for
+// concurrent use.
+type FileHandler struct {
+	redirector
+	reloadRecorder
+	path    string
+	entries atomic.Pointer[map[string]ShortenedUrl]
+	watcher *fsnotify.Watcher
+	done    sync.WaitGroup
+}
+
+// NewFileHandler reads the config file at path and returns a
+// FileHandler serving its mappings. The format of the file is
+// picked from its extension: .yaml, .yml, .json, .ndjson,
+// .jsonl, .toml, .csv, .tsv, .xml, .ini, .properties, .msgpack,
+// .hcl, .pb or .binpb.
+// If a path is not mapped, then the fallback http.Handler will
+// be called instead.
+//
+// The file is then watched for changes. When it changes, the
+// new mappings replace the old ones at once. If the new content
+// cannot be loaded, the error is logged and the previous
+// mappings keep being served.
+//
+// Call Close to stop watching the file.
+func NewFileHandler(path string, fallback http.Handler) (*FileHandler, error) {
+	opts, _ := Options{}.withDefaults()
+	fh := &FileHandler{
+		redirector: redirector{opts: opts, fallback: fallback},
+		path:       path,
+	}
+	if err := fh.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Editors often save by replacing the file, which would drop a
+	// watch on the file itself, so watch its directory instead.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	fh.watcher = watcher
+	fh.done.Add(1)
+	go fh.watch()
+	return fh, nil
+}
+
+// HandlerFromFile reads the config file at path once and
+// returns a handler serving its mappings, picking the format
+// from the extension of the file as NewFileHandler does. Use
+// NewFileHandler instead to pick up later changes to the file.
+// If a path is not mapped, then the fallback http.Handler will
+// be called instead. The handler is the one UrlsHandler returns,
+// so it implements Matcher, PathLooker and Pinger as well.
+//
+// An error is returned if the file has no extension or one of
+// an unsupported format, in which case it wraps
+// ErrUnsupportedFormat, cannot be read or parsed, or maps a path
+// to two different urls.
+func HandlerFromFile(path string, fallback http.Handler) (http.Handler, error) {
+	urls, err := parseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return UrlsHandler(urls, Options{}, fallback)
+}
+
+// HandlerFromFS works like HandlerFromFile, but reads the file
+// name from fsys, such as an embed.FS, so that a config built
+// into the binary can be served without touching the OS
+// filesystem. As with any fs.FS, name is slash-separated and
+// unrooted, for example:
+//
+//	//go:embed config/urls.yaml
+//	var config embed.FS
+//
+//	handler, err := urlshort.HandlerFromFS(config, "config/urls.yaml", mux)
+func HandlerFromFS(fsys fs.FS, name string, fallback http.Handler) (http.Handler, error) {
+	parse, err := parserForFile(name)
+	if err != nil {
+		return nil, err
+	}
+	input, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	urls, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	setSource(urls, name)
+	return UrlsHandler(urls, Options{}, fallback)
+}
+
+func (fh *FileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fh.serve(w, r, fh.lookup)
+}
+
+// Close stops watching the file, implementing io.Closer. It
+// returns once the background goroutine has exited and the
+// watcher is released, so nothing is left running, and it can
+// be called more than once. The handler keeps serving the
+// mappings it had loaded last.
+func (fh *FileHandler) Close() error {
+	err := fh.watcher.Close()
+	fh.done.Wait()
+	return err
+}
+
+// Match implements Matcher.
+func (fh *FileHandler) Match(r *http.Request) (string, bool) {
+	return fh.matchRequest(r, fh.lookup)
+}
+
+func (fh *FileHandler) lookup(key string) (ShortenedUrl, bool) {
+	entry, exists := (*fh.entries.Load())[key]
+	return entry, exists
+}
+
+func (fh *FileHandler) watch() {
+	defer fh.done.Done()
+	name := filepath.Clean(fh.path)
+
+	// A single save usually produces several events, the first of
+	// which may see a truncated file, so only reload once the file
+	// has been quiet for a moment.
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case event, ok := <-fh.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != name || !event.Has(fsnotify.Write|fsnotify.Create) {
+				continue
+			}
+			debounce.Reset(reloadDelay)
+		case <-debounce.C:
+			if err := fh.reload(); err != nil {
+				fh.opts.logger().Error("Error while reloading file, keeping previous urls",
+					slog.String("file", fh.path), slog.Any("error", err))
+				continue
+			}
+			fh.opts.logger().Info("Reloaded urls from file", slog.String("file", fh.path))
+		case err, ok := <-fh.watcher.Errors:
+			if !ok {
+				return
+			}
+			fh.opts.logger().Error("Error while watching file",
+				slog.String("file", fh.path), slog.Any("error", err))
+		}
+	}
+}
+
+func (fh *FileHandler) reload() error {
+	return fh.recordReload(fh.load())
+}
+
+func (fh *FileHandler) load() error {
+	urls, err := parseFile(fh.path)
+	if err != nil {
+		return err
+	}
+	entries, err := fh.opts.buildEntries(urls)
+	if err != nil {
+		return err
+	}
+	fh.entries.Store(&entries)
+	return nil
+}
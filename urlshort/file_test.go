@@ -0,0 +1,108 @@
+package urlshort
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// writeConfig writes data to a file with the given name in a
+// temporary directory and returns its path.
+func writeConfig(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHandlerFromFile(t *testing.T) {
+	path := writeConfig(t, "urls.yaml", "- path: /a\n  url: https://example.com/a\n")
+	handler, err := HandlerFromFile(path, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "miss", target: "/b", status: http.StatusNotFound},
+	})
+	if _, ok := handler.(Matcher); !ok {
+		t.Error("handler does not implement Matcher")
+	}
+	if _, ok := handler.(Pinger); !ok {
+		t.Error("handler does not implement Pinger")
+	}
+	looker, ok := handler.(PathLooker)
+	if !ok {
+		t.Fatal("handler does not implement PathLooker")
+	}
+	if url, _, matched := looker.LookupPath("/a"); !matched || url != "https://example.com/a" {
+		t.Errorf("LookupPath(/a) = %q, %v", url, matched)
+	}
+}
+
+func TestHandlerFromFileErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, file, data string
+	}{
+		{name: "no extension", file: "urls", data: "[]"},
+		{name: "unsupported", file: "urls.docx", data: "[]"},
+		{name: "malformed", file: "urls.json", data: "[{"},
+		{name: "duplicate", file: "urls.json", data: `[
+			{"path": "/a", "url": "https://example.com/1"},
+			{"path": "/a", "url": "https://example.com/2"}
+		]`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeConfig(t, tc.file, tc.data)
+			if _, err := HandlerFromFile(path, http.NotFoundHandler()); err == nil {
+				t.Error("HandlerFromFile() = nil error")
+			}
+		})
+	}
+	if _, err := HandlerFromFile(filepath.Join(t.TempDir(), "missing.yaml"), http.NotFoundHandler()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("HandlerFromFile(missing) = %v, want os.ErrNotExist", err)
+	}
+	if _, err := HandlerFromFile(writeConfig(t, "urls.docx", ""), http.NotFoundHandler()); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("HandlerFromFile(docx) = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestHandlerFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/urls.json": {Data: []byte(`[{"path": "/a", "url": "https://example.com/a"}]`)},
+		"config/bad.toml":  {Data: []byte("path = ")},
+	}
+	handler, err := HandlerFromFS(fsys, "config/urls.json", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "miss", target: "/b", status: http.StatusNotFound},
+	})
+	for _, check := range []func(http.Handler) bool{
+		func(h http.Handler) bool { _, ok := h.(Matcher); return ok },
+		func(h http.Handler) bool { _, ok := h.(PathLooker); return ok },
+		func(h http.Handler) bool { _, ok := h.(Pinger); return ok },
+	} {
+		if !check(handler) {
+			t.Error("handler lost one of Matcher, PathLooker and Pinger")
+		}
+	}
+
+	if _, err := HandlerFromFS(fsys, "config/missing.json", http.NotFoundHandler()); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("HandlerFromFS(missing) = %v, want fs.ErrNotExist", err)
+	}
+	if _, err := HandlerFromFS(fsys, "config/bad.toml", http.NotFoundHandler()); err == nil {
+		t.Error("HandlerFromFS(malformed) = nil error")
+	}
+	if _, err := HandlerFromFS(fsys, "config/urls", http.NotFoundHandler()); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("HandlerFromFS(no extension) = %v, want ErrUnsupportedFormat", err)
+	}
+}
@@ -0,0 +1,76 @@
+package urlshort
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// parserForFormat returns the parser for the named config
+// format, such as "yaml" or "json".
+func parserForFormat(format string) (func([]byte) (ShortenedUrls, error), error) {
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		return ParseYAML, nil
+	case "json":
+		return ParseJSON, nil
+	case "ndjson", "jsonl":
+		return parseNDJSON, nil
+	case "toml":
+		return parseTOML, nil
+	case "csv":
+		return parseCSV, nil
+	case "tsv":
+		return parseTSV, nil
+	case "xml":
+		return parseXML, nil
+	case "ini", "properties":
+		return parseINI, nil
+	case "msgpack":
+		return parseMsgPack, nil
+	case "hcl":
+		return parseHCL, nil
+	case "protobuf", "pb", "binpb":
+		return parseProto, nil
+	default:
+		return nil, fmt.Errorf("%w '%s'", ErrUnsupportedFormat, format)
+	}
+}
+
+// parserForFile returns the parser for the config format that
+// matches the extension of name.
+func parserForFile(name string) (func([]byte) (ShortenedUrls, error), error) {
+	ext := filepath.Ext(name)
+	if ext == "" {
+		return nil, fmt.Errorf("%w: config file '%s' has no extension", ErrUnsupportedFormat, name)
+	}
+	return parserForFormat(strings.TrimPrefix(ext, "."))
+}
+
+// ValidateConfig parses input in the given format and runs
+// every check on the result, without building a handler. It is
+// meant for checking a config before deploying it, for example
+// in CI. The format is one of yaml, yml, json, ndjson, jsonl,
+// toml, csv, tsv, xml, ini, properties, msgpack, hcl, protobuf,
+// pb or binpb. Any other format gives an error wrapping
+// ErrUnsupportedFormat.
+//
+// The checks are those UrlsHandler runs with default options,
+// and that no path is empty and every url is an absolute http
+// or https url. Disabled entries are left out, as handlers do,
+// so a disabled entry never conflicts with an enabled one. If
+// any check fails, the error is a *ConfigError listing every
+// problem found.
+// The parsed ShortenedUrls are returned even when checks fail,
+// as long as the input could be parsed.
+func ValidateConfig(input []byte, format string) (ShortenedUrls, error) {
+	parse, err := parserForFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	urls, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return urls, validateConfig(urls)
+}
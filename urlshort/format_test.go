@@ -0,0 +1,64 @@
+package urlshort
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input string
+		// problems is the number of problems expected, or 0 if
+		// the config is valid.
+		problems int
+	}{
+		{name: "valid", input: `[{"path": "/a", "url": "https://example.com"}]`},
+		{name: "disabled duplicate", input: `[
+			{"path": "/a", "url": "https://example.com/old", "enabled": false},
+			{"path": "/a", "url": "https://example.com/new"}
+		]`},
+		{name: "aliases", input: `[{"paths": ["/gh", "/github"], "url": "https://github.com"}]`},
+		{name: "relative url", input: `[{"path": "/a", "url": "example.com"}]`, problems: 1},
+		{name: "empty path", input: `[{"url": "https://example.com"}]`, problems: 1},
+		{name: "duplicate", input: `[
+			{"path": "/a", "url": "https://example.com/1"},
+			{"path": "/a", "url": "https://example.com/2"}
+		]`, problems: 1},
+		{name: "alias conflict", input: `[
+			{"path": "/gh", "url": "https://github.com"},
+			{"path": "/x", "paths": ["/gh"], "url": "https://example.com"}
+		]`, problems: 1},
+		{name: "normalized duplicate", input: `[
+			{"path": "/a", "url": "https://example.com/1"},
+			{"path": "/%61", "url": "https://example.com/2"}
+		]`, problems: 1},
+		{name: "status", input: `[{"path": "/a", "url": "https://example.com", "status": 42}]`, problems: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			urls, err := ValidateConfig([]byte(tc.input), "json")
+			if len(urls) == 0 {
+				t.Fatalf("ValidateConfig() returned no urls")
+			}
+			if tc.problems == 0 {
+				if err != nil {
+					t.Fatalf("ValidateConfig() = %v, want nil", err)
+				}
+				return
+			}
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("ValidateConfig() = %v, want a *ConfigError", err)
+			}
+			if len(configErr.Problems) != tc.problems {
+				t.Errorf("problems = %+v, want %d", configErr.Problems, tc.problems)
+			}
+		})
+	}
+}
+
+func TestValidateConfigUnsupportedFormat(t *testing.T) {
+	if _, err := ValidateConfig([]byte("{}"), "docx"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("ValidateConfig() = %v, want ErrUnsupportedFormat", err)
+	}
+}
@@ -0,0 +1,48 @@
+package urlshort
+
+import "net/http"
+
+type funcRedirector struct {
+	redirector
+	resolve func(r *http.Request) (string, bool)
+}
+
+// FuncHandler will return an http.Handler that calls lookup
+// for each request and redirects it, with the given status, to
+// the url lookup returns when it also returns true. Otherwise,
+// the fallback http.Handler will be called instead. This lets
+// redirects come from any source, such as feature flags or a
+// cache, without a dedicated handler. Requests are logged the
+// same way as those served by MapHandler.
+//
+// The status must be one of 301, 302, 307 or 308, otherwise an
+// error is returned. lookup may be called concurrently.
+func FuncHandler(lookup func(r *http.Request) (string, bool), status int, fallback http.Handler) (http.Handler, error) {
+	if err := validateStatus(status); err != nil {
+		return nil, err
+	}
+	opts, _ := Options{Status: status}.withDefaults()
+	return &funcRedirector{
+		redirector: redirector{opts: opts, fallback: fallback},
+		resolve:    lookup,
+	}, nil
+}
+
+func (fr *funcRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fr.serve(w, r, fr.lookup(r))
+}
+
+// Match implements Matcher.
+func (fr *funcRedirector) Match(r *http.Request) (string, bool) {
+	return fr.matchRequest(r, fr.lookup(r))
+}
+
+func (fr *funcRedirector) lookup(r *http.Request) lookupFunc {
+	return func(key string) (ShortenedUrl, bool) {
+		url, found := fr.resolve(r)
+		if !found {
+			return ShortenedUrl{}, false
+		}
+		return ShortenedUrl{Path: key, Url: url}, true
+	}
+}
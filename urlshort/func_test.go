@@ -0,0 +1,46 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFuncHandler(t *testing.T) {
+	var calls atomic.Int64
+	handler, err := FuncHandler(func(r *http.Request) (string, bool) {
+		calls.Add(1)
+		if name, found := strings.CutPrefix(r.URL.Path, "/gh/"); found {
+			return "https://github.com/" + name, true
+		}
+		if r.Header.Get("X-Beta") == "1" {
+			return "https://beta.example.com" + r.URL.Path, true
+		}
+		return "", false
+	}, http.StatusFound, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "resolved", target: "/gh/golang", status: http.StatusFound, location: "https://github.com/golang"},
+		{name: "from headers", target: "/x", header: http.Header{"X-Beta": {"1"}}, status: http.StatusFound, location: "https://beta.example.com/x"},
+		{name: "miss", target: "/x", status: http.StatusNotFound},
+	})
+	if calls.Load() != 3 {
+		t.Errorf("lookup called %d times, want once per request", calls.Load())
+	}
+	if url, matched := handler.(Matcher).Match(httptest.NewRequest(http.MethodGet, "/gh/go", nil)); !matched || url != "https://github.com/go" {
+		t.Errorf("Match() = %q, %v", url, matched)
+	}
+}
+
+func TestFuncHandlerStatus(t *testing.T) {
+	lookup := func(*http.Request) (string, bool) { return "", false }
+	for _, status := range []int{0, http.StatusOK, http.StatusNotModified, http.StatusGone} {
+		if _, err := FuncHandler(lookup, status, http.NotFoundHandler()); err == nil {
+			t.Errorf("FuncHandler(status %d) = nil error", status)
+		}
+	}
+}
@@ -1,35 +1,524 @@
 package urlshort
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
 )
 
 type ShortenedUrl struct {
-	Path string `json:"path" yaml:"path"`
-	Url  string `json:"url" yaml:"url"`
+	Path string `json:"path" msgpack:"path" yaml:"path" toml:"path" hcl:"path,optional" xml:"path"`
+	Url  string `json:"url" msgpack:"url" yaml:"url" toml:"url" hcl:"url,optional" xml:"url"`
+
+	// Paths lists aliases of Path, which all redirect the same
+	// way, such as /gh and /github. Path may be left empty when
+	// Paths is set. Each alias is matched, and checked for
+	// conflicts with other entries, as if it were an entry of
+	// its own.
+	Paths []string `json:"paths,omitempty" msgpack:"paths,omitempty" yaml:"paths,omitempty" toml:"paths,omitempty" hcl:"paths,optional" xml:"paths>path,omitempty"`
+
+	// ExpiresAt is when the entry stops redirecting, after which
+	// requests for its path are treated as a miss. Entries
+	// without an expiry never expire.
+	ExpiresAt *time.Time `json:"expires_at,omitempty" msgpack:"expires_at,omitempty" yaml:"expires_at,omitempty" toml:"expires_at,omitempty" xml:"expires_at,omitempty"`
+
+	// NotBefore is when the entry starts redirecting, before
+	// which requests for its path are treated as a miss, for
+	// example to publish a link ahead of a launch. Entries without
+	// it are active from the start. With ExpiresAt set as well,
+	// the entry is active from NotBefore until ExpiresAt.
+	NotBefore *time.Time `json:"not_before,omitempty" msgpack:"not_before,omitempty" yaml:"not_before,omitempty" toml:"not_before,omitempty" xml:"not_before,omitempty"`
+
+	// Status, when set, is the HTTP status code used to redirect
+	// to the entry instead of the one of the handler, for example
+	// 302 for a temporary link among permanent ones. It must be
+	// one of 301, 302, 307 or 308.
+	Status int `json:"status,omitempty" msgpack:"status,omitempty" yaml:"status,omitempty" toml:"status,omitempty" hcl:"status,optional" xml:"status,omitempty"`
+
+	// Methods lists the HTTP methods the entry redirects, such as
+	// GET and HEAD. Requests using other methods get a 405. When
+	// empty, the methods allowed by the handler options are used.
+	Methods []string `json:"methods,omitempty" msgpack:"methods,omitempty" yaml:"methods,omitempty" toml:"methods,omitempty" hcl:"methods,optional" xml:"methods>method,omitempty"`
+
+	// Description explains why the entry exists, for people
+	// reading or managing the config. It plays no part in
+	// matching.
+	Description string `json:"description,omitempty" msgpack:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty" hcl:"description,optional" xml:"description,omitempty"`
+
+	// Targets, when set, lists several urls the entry redirects
+	// to, one picked at random for each request, and Url is not
+	// used. Weights are relative: each target is picked with a
+	// chance of its weight divided by the sum of all weights, so
+	// weights of 1 and 3 work the same as 25 and 75. Targets with
+	// a weight of zero are never picked, unless every weight is
+	// zero, in which case all are equally likely. Each target
+	// owns a slice of the random range, in the order listed, so a
+	// fixed Options.Random always picks the same target.
+	Targets []Target `json:"targets,omitempty" msgpack:"targets,omitempty" yaml:"targets,omitempty" toml:"targets,omitempty" hcl:"target,block" xml:"targets>target,omitempty"`
+
+	// Gone marks an entry that was retired on purpose. Requests
+	// for its path get a 410 Gone without a Location header,
+	// whatever the redirect status of the handler is, instead of
+	// a redirect or the fallback. Url and Targets are not used,
+	// and may be left empty.
+	Gone bool `json:"gone,omitempty" msgpack:"gone,omitempty" yaml:"gone,omitempty" toml:"gone,omitempty" hcl:"gone,optional" xml:"gone,omitempty"`
+
+	// MaxHits, when set, is how many requests the entry serves,
+	// for example for a giveaway limited to the first hundred,
+	// after which requests for its path get a 410 Gone as for a
+	// Gone entry. Hits are counted in memory by the handler, from
+	// zero each time it is built or reloads its mappings, and are
+	// never reset otherwise; several instances of a handler each
+	// count their own. Aliases share the count of their entry.
+	// Redirects, proxied requests and interstitial pages count;
+	// OPTIONS, 405s and canonical redirects do not. Handlers
+	// looking up each request in a database or Redis ignore it.
+	MaxHits int64 `json:"max_hits,omitempty" msgpack:"max_hits,omitempty" yaml:"max_hits,omitempty" toml:"max_hits,omitempty" hcl:"max_hits,optional" xml:"max_hits,omitempty"`
+
+	// Query lists query parameters a request must have, with
+	// those exact values, for the entry to match. It is only
+	// understood by QueryHandler, which allows several entries
+	// with the same path but different Query.
+	Query map[string]string `json:"query,omitempty" msgpack:"query,omitempty" yaml:"query,omitempty" toml:"query,omitempty" hcl:"query,optional" xml:"-"`
+
+	// MatchHeaders lists request headers a request must have,
+	// with those exact values, for the entry to match, such as a
+	// tenant header. Like Query, it is only understood by
+	// QueryHandler, and both must match when both are set.
+	MatchHeaders map[string]string `json:"match_headers,omitempty" msgpack:"match_headers,omitempty" yaml:"match_headers,omitempty" toml:"match_headers,omitempty" hcl:"match_headers,optional" xml:"-"`
+
+	// Headers lists extra response headers to send with the
+	// redirect, such as Referrer-Policy. They are set after the
+	// headers the handler options ask for, so they can override
+	// them, but before Location, which they cannot.
+	Headers map[string]string `json:"headers,omitempty" msgpack:"headers,omitempty" yaml:"headers,omitempty" toml:"headers,omitempty" hcl:"headers,optional" xml:"-"`
+
+	// Proxy makes requests for the entry get the content of its
+	// url through a reverse proxy, instead of a redirect, so the
+	// short url stays in the address bar. See Options.Proxy.
+	Proxy bool `json:"proxy,omitempty" msgpack:"proxy,omitempty" yaml:"proxy,omitempty" toml:"proxy,omitempty" hcl:"proxy,optional" xml:"proxy,omitempty"`
+
+	// Interstitial makes requests for the entry get an HTML page
+	// leading on to its url after a short delay, instead of a
+	// redirect. See Options.Interstitial.
+	Interstitial bool `json:"interstitial,omitempty" msgpack:"interstitial,omitempty" yaml:"interstitial,omitempty" toml:"interstitial,omitempty" hcl:"interstitial,optional" xml:"interstitial,omitempty"`
+
+	// Enabled, when set to false, keeps the entry in the config
+	// without serving it, for example to stage a link before its
+	// launch. Requests for its path are treated as a miss. When
+	// nil, the entry is enabled.
+	Enabled *bool `json:"enabled,omitempty" msgpack:"enabled,omitempty" yaml:"enabled,omitempty" toml:"enabled,omitempty" hcl:"enabled,optional" xml:"enabled,omitempty"`
+
+	// hits counts the requests served for MaxHits, shared by the
+	// paths of the entry. It is set when the handler is built.
+	hits *atomic.Int64
+
+	// source is the config file the entry was read from, if
+	// known, for the Debug option.
+	source string
+}
+
+// IsEnabled reports whether the entry is enabled, which is the
+// case unless Enabled is set to false.
+func (u ShortenedUrl) IsEnabled() bool {
+	return u.Enabled == nil || *u.Enabled
+}
+
+// entryPaths returns every path entry is served under: its
+// Path, unless empty, followed by its aliases.
+func entryPaths(entry ShortenedUrl) []string {
+	if entry.Path == "" {
+		return entry.Paths
+	}
+	return append([]string{entry.Path}, entry.Paths...)
 }
 
 type ShortenedUrls []ShortenedUrl
 
-type pathRedirector struct {
-	pathsToUrls map[string]string
-	fallback    http.Handler
+// lookupFunc finds the entry stored under a key, if any.
+type lookupFunc func(key string) (ShortenedUrl, bool)
+
+// redirector holds the behavior shared by every handler in
+// this package: redirecting matched paths and passing the rest
+// to the fallback.
+type redirector struct {
+	opts     Options
+	fallback http.Handler
 }
 
-func (pr *pathRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	slog.Info("Request url: " + r.URL.String())
-	path := r.URL.Path
-	if url, exists := pr.pathsToUrls[path]; exists {
-		slog.Info("Redirecting to " + url)
-		http.Redirect(w, r, url, http.StatusMovedPermanently)
+// serve redirects r to the url that lookup returns for its
+// path, or calls the fallback if lookup finds nothing. A url
+// that would redirect r to itself, looping forever, is logged
+// and treated as a miss. An OPTIONS request for a matched path
+// gets a 204 listing the allowed methods, rather than a
+// redirect, unless the entry is proxied. Requests without a
+// path, or for "*", are handled by serveSpecialPath.
+func (rd *redirector) serve(w http.ResponseWriter, r *http.Request, lookup lookupFunc) {
+	if rd.opts.OnServed != nil {
+		start := time.Now()
+		defer func() {
+			rd.opts.OnServed(r, time.Since(start))
+		}()
+	}
+	if status := rd.opts.rejectPath(r.URL.Path); status != 0 {
+		rd.log(r, slog.LevelWarn, "Rejecting path",
+			slog.Int("length", len(r.URL.Path)),
+			slog.Int("status", status),
+			slog.Bool("fallback", false))
+		rd.error(w, r, status)
+		return
+	}
+	if rd.serveSpecialPath(w, r, lookup) {
+		return
+	}
+	path := rd.opts.normalizePath(r.URL.Path)
+	if key, entry, exists := rd.match(path, lookup); exists {
+		rd.debugMatch(w, key, entry)
+		if r.Method == http.MethodOptions && !rd.opts.Proxy && !entry.Proxy {
+			rd.log(r, rd.opts.redirectLevel(), "Answering OPTIONS",
+				slog.String("path", path),
+				slog.String("match", key),
+				slog.Int("status", http.StatusNoContent),
+				slog.Bool("fallback", false))
+			w.Header().Set("Allow", strings.Join(optionsAllow(rd.allowedMethods(entry)), ", "))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if canonical, redirect := rd.canonicalURL(r, path); redirect {
+			rd.log(r, rd.opts.redirectLevel(), "Redirecting to canonical path",
+				slog.String("path", path),
+				slog.String("match", key),
+				slog.String("url", canonical),
+				slog.Int("status", http.StatusMovedPermanently),
+				slog.Bool("fallback", false))
+			rd.redirect(w, r, canonical, http.StatusMovedPermanently, nil)
+			return
+		}
+		if allowed := rd.allowedMethods(entry); !methodAllowed(allowed, r.Method) {
+			rd.log(r, rd.opts.redirectLevel(), "Method not allowed",
+				slog.String("path", path),
+				slog.String("match", key),
+				slog.String("method", r.Method),
+				slog.Int("status", http.StatusMethodNotAllowed),
+				slog.Bool("fallback", false))
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			rd.error(w, r, http.StatusMethodNotAllowed)
+			return
+		}
+		if entry.Gone || entry.usedUp() {
+			rd.log(r, rd.opts.redirectLevel(), "Path is gone",
+				slog.String("path", path),
+				slog.String("match", key),
+				slog.Int("status", http.StatusGone),
+				slog.Bool("fallback", false))
+			rd.error(w, r, http.StatusGone)
+			return
+		}
+		url := rd.rewrite(r, rd.opts.destination(entry.Url, r))
+		if !redirectsToItself(r, url) {
+			if !entry.takeHit() {
+				rd.log(r, rd.opts.redirectLevel(), "Path is used up",
+					slog.String("path", path),
+					slog.String("match", key),
+					slog.Int64("max_hits", entry.MaxHits),
+					slog.Int("status", http.StatusGone),
+					slog.Bool("fallback", false))
+				rd.error(w, r, http.StatusGone)
+				return
+			}
+			if rd.opts.OnRedirect != nil {
+				rd.opts.OnRedirect(r, key, url)
+			}
+			if rd.opts.Proxy || entry.Proxy {
+				rd.log(r, rd.opts.redirectLevel(), "Proxying",
+					slog.String("path", path),
+					slog.String("match", key),
+					slog.String("url", url),
+					slog.Bool("fallback", false))
+				rd.proxy(w, r, url, entry.Headers)
+				return
+			}
+			if rd.opts.Interstitial || entry.Interstitial {
+				rd.log(r, rd.opts.redirectLevel(), "Showing interstitial",
+					slog.String("path", path),
+					slog.String("match", key),
+					slog.String("url", url),
+					slog.Bool("fallback", false))
+				rd.interstitial(w, r, path, url, entry.Headers)
+				return
+			}
+			rd.log(r, rd.opts.redirectLevel(), "Redirecting",
+				slog.String("path", path),
+				slog.String("match", key),
+				slog.String("url", url),
+				slog.Int("status", rd.opts.status(entry)),
+				slog.Bool("fallback", false))
+			rd.redirect(w, r, url, rd.opts.status(entry), entry.Headers)
+			return
+		}
+		rd.log(r, slog.LevelWarn, "Url redirects to itself, treating as miss",
+			slog.String("path", path),
+			slog.String("match", key),
+			slog.String("url", url))
+	}
+	rd.debugMiss(w)
+	if rd.opts.OnMiss != nil {
+		rd.opts.OnMiss(r)
+	}
+	if rd.opts.DefaultURL != "" {
+		url := rd.opts.destination(rd.opts.DefaultURL, r)
+		if !redirectsToItself(r, url) {
+			rd.log(r, rd.opts.redirectLevel(), "No url in map, redirecting to default",
+				slog.String("path", path),
+				slog.String("url", url),
+				slog.Int("status", rd.opts.Status),
+				slog.Bool("fallback", false))
+			rd.redirect(w, r, url, rd.opts.Status, nil)
+			return
+		}
+	}
+	rd.log(r, rd.opts.missLevel(), "No url in map",
+		slog.String("path", path),
+		slog.Bool("fallback", true))
+	rd.fallback.ServeHTTP(w, r)
+}
+
+// canonicalURL returns the url of r with its path replaced by
+// the normalized path, and true, if the options ask for
+// canonical redirects and the path of r is not normalized.
+func (rd *redirector) canonicalURL(r *http.Request, path string) (string, bool) {
+	if !rd.opts.CanonicalRedirect || path == r.URL.Path {
+		return "", false
+	}
+	canonical := &url.URL{Path: path, RawQuery: r.URL.RawQuery}
+	return canonical.String(), true
+}
+
+// redirect writes a redirect to url with the given status,
+// along with the headers the options ask for and then headers.
+// The short HTML body http.Redirect writes for GET, or the one
+// rendered from the RedirectTemplate option, is left out for
+// HEAD, and for other methods, which 307 and 308 tell the
+// client to repeat.
+func (rd *redirector) redirect(w http.ResponseWriter, r *http.Request, url string, status int, headers map[string]string) {
+	rd.setHeaders(w, headers)
+	if rd.opts.RedirectTemplate != nil && r.Method == http.MethodGet {
+		rd.redirectPage(w, r, url, status)
+		return
+	}
+	http.Redirect(w, r, url, status)
+}
+
+// setHeaders sets the headers the options ask for, and then
+// headers, which can override them.
+func (rd *redirector) setHeaders(w http.ResponseWriter, headers map[string]string) {
+	if rd.opts.CacheControl != "" {
+		w.Header().Set("Cache-Control", rd.opts.CacheControl)
+	}
+	for name, value := range headers {
+		w.Header().Set(name, value)
+	}
+}
+
+// error writes an error response with the given status and its
+// text as the body, or no body at all for HEAD.
+func (rd *redirector) error(w http.ResponseWriter, r *http.Request, status int) {
+	if r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(status), status)
 		return
 	}
-	slog.Warn("No url in map")
-	pr.fallback.ServeHTTP(w, r)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+}
+
+// log writes a request log line with the given attributes,
+// plus the requested url and, if configured, the request ID and
+// the attributes from the request context. Lines may be left
+// out as the LogSampleRate option asks.
+func (rd *redirector) log(r *http.Request, level slog.Level, msg string, attrs ...slog.Attr) {
+	if !rd.opts.sampleLog(level) {
+		return
+	}
+	attrs = append(attrs, slog.String("request", r.URL.String()))
+	if rd.opts.RequestIDHeader != "" {
+		if id := r.Header.Get(rd.opts.RequestIDHeader); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+	}
+	if rd.opts.ContextAttrs != nil {
+		attrs = append(attrs, rd.opts.ContextAttrs(r.Context())...)
+	}
+	rd.opts.logger().LogAttrs(r.Context(), level, msg, attrs...)
+}
+
+// matchRequest reports where serve would redirect r to, if it
+// would redirect it at all rather than call the fallback. For
+// an entry that is gone, the url is empty. A path the options
+// reject is reported as a miss.
+func (rd *redirector) matchRequest(r *http.Request, lookup lookupFunc) (string, bool) {
+	url, _, matched := rd.matchStatus(r, lookup)
+	return url, matched
+}
+
+// matchStatus works like matchRequest, but also reports the
+// status serve would answer r with: 410 for an entry that is
+// gone, 200 for an interstitial page, and 0 for a proxied entry,
+// whose status comes from its url.
+func (rd *redirector) matchStatus(r *http.Request, lookup lookupFunc) (string, int, bool) {
+	if rd.opts.rejectPath(r.URL.Path) != 0 || rd.specialPath(r.URL.Path, lookup) {
+		return "", 0, false
+	}
+	path := rd.opts.normalizePath(r.URL.Path)
+	if _, entry, exists := rd.match(path, lookup); exists {
+		if canonical, redirect := rd.canonicalURL(r, path); redirect {
+			return canonical, http.StatusMovedPermanently, true
+		}
+		if entry.Gone || entry.usedUp() {
+			return "", http.StatusGone, true
+		}
+		if url := rd.rewrite(r, rd.opts.destination(entry.Url, r)); !redirectsToItself(r, url) {
+			switch {
+			case rd.opts.Proxy || entry.Proxy:
+				return url, 0, true
+			case rd.opts.Interstitial || entry.Interstitial:
+				return url, http.StatusOK, true
+			}
+			return url, rd.opts.status(entry), true
+		}
+	}
+	if rd.opts.DefaultURL != "" {
+		if url := rd.opts.destination(rd.opts.DefaultURL, r); !redirectsToItself(r, url) {
+			return url, rd.opts.Status, true
+		}
+	}
+	return "", 0, false
+}
+
+// match finds the entry for path using lookup, along with the
+// key it was found under. An exact match is tried first, along
+// with the alternate form of the path if there is one. Then,
+// if prefix matching is enabled, wildcard keys are tried from
+// the longest prefix to the shortest, and the rest of the path
+// is appended to the url of the entry found.
+//
+// Entries that are not active, such as expired ones, are
+// skipped as if they were not there. The root path matches
+// RootURL before anything else, if it is set.
+func (rd *redirector) match(path string, lookup lookupFunc) (string, ShortenedUrl, bool) {
+	if rd.opts.RootURL != "" && path == "/" {
+		return "/", ShortenedUrl{Path: "/", Url: rd.opts.RootURL}, true
+	}
+	find := func(key string) (ShortenedUrl, bool) {
+		entry, exists := lookup(key)
+		if !exists || !rd.active(entry) {
+			return ShortenedUrl{}, false
+		}
+		if len(entry.Targets) > 0 && !entry.Gone {
+			entry.Url = rd.opts.pickTarget(entry.Targets)
+		}
+		return entry, true
+	}
+	if entry, exists := find(path); exists {
+		return path, entry, true
+	}
+	if alternate, ok := rd.opts.alternatePath(path); ok {
+		if entry, exists := find(alternate); exists {
+			return alternate, entry, true
+		}
+	}
+	if !rd.opts.PrefixMatching {
+		return "", ShortenedUrl{}, false
+	}
+	for i := strings.LastIndex(path, "/"); i >= 0; i = strings.LastIndex(path[:i], "/") {
+		key := path[:i+1] + "*"
+		if entry, exists := find(key); exists {
+			entry.Url = joinPath(entry.Url, path[i+1:])
+			return key, entry, true
+		}
+	}
+	return "", ShortenedUrl{}, false
+}
+
+// active reports whether entry should redirect at this time.
+func (rd *redirector) active(entry ShortenedUrl) bool {
+	if !entry.IsEnabled() {
+		return false
+	}
+	return entry.StateAt(rd.opts.now()) == ScheduleActive
+}
+
+// allowedMethods returns the methods entry may be requested
+// with, or nil if any method is allowed.
+func (rd *redirector) allowedMethods(entry ShortenedUrl) []string {
+	if len(entry.Methods) > 0 {
+		return entry.Methods
+	}
+	return rd.opts.Methods
+}
+
+// optionsAllow returns the methods to list in the Allow header
+// of a response to OPTIONS, given the methods an entry is
+// allowed with: those methods plus OPTIONS, or the common
+// methods if any method is allowed.
+func optionsAllow(allowed []string) []string {
+	if len(allowed) == 0 {
+		return []string{
+			http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+			http.MethodPatch, http.MethodDelete, http.MethodOptions,
+		}
+	}
+	if methodAllowed(allowed, http.MethodOptions) {
+		return allowed
+	}
+	return append(slices.Clip(allowed), http.MethodOptions)
+}
+
+// methodAllowed reports whether method is in allowed. An empty
+// list allows every method.
+func methodAllowed(allowed []string, method string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, m := range allowed {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+type pathRedirector struct {
+	redirector
+	entries map[string]ShortenedUrl
+}
+
+func (pr *pathRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pr.serve(w, r, pr.lookup)
+}
+
+// Match implements Matcher.
+func (pr *pathRedirector) Match(r *http.Request) (string, bool) {
+	return pr.matchRequest(r, pr.lookup)
+}
+
+func (pr *pathRedirector) lookup(key string) (ShortenedUrl, bool) {
+	entry, exists := pr.entries[key]
+	return entry, exists
 }
 
 // MapHandler will return an http.HandlerFunc (which also
@@ -38,13 +527,134 @@ func (pr *pathRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // that each key in the map points to, in string format).
 // If the path is not provided in the map, then the fallback
 // http.Handler will be called instead.
+//
+// The handler also implements Store, so it can back a handler
+// built with HandlerFromStore, such as behind a store of your
+// own.
+//
+// Paths are matched once decoded, so two keys such as
+// /caf%C3%A9 and /café name the same path. Rather than failing,
+// the key sorting last wins. Use MapHandlerWithOptions to get an
+// error instead.
 func MapHandler(pathsToUrls map[string]string, fallback http.Handler) http.Handler {
+	return newLenientMapHandler(pathsToUrls, Options{}, fallback)
+}
+
+// MapHandlerOr404 works like MapHandler, but instead of calling
+// a fallback handler, it responds to unmapped paths with a 404
+// and notFoundBody as a plain text body.
+func MapHandlerOr404(pathsToUrls map[string]string, notFoundBody string) http.Handler {
+	return MapHandler(pathsToUrls, notFoundText(notFoundBody))
+}
+
+// MapHandlerWithStatus works like MapHandler, but redirects with
+// the given status code instead of http.StatusMovedPermanently.
+// Use http.StatusFound or http.StatusTemporaryRedirect for links
+// that may change, since browsers cache permanent redirects, and
+// http.StatusTemporaryRedirect or http.StatusPermanentRedirect
+// for links clients POST to, since only those make clients
+// keep the method and body. See Options.Status.
+//
+// The status must be one of 301, 302, 307 or 308, otherwise an
+// error is returned.
+func MapHandlerWithStatus(pathsToUrls map[string]string, status int, fallback http.Handler) (http.Handler, error) {
+	if err := validateStatus(status); err != nil {
+		return nil, err
+	}
+	return MapHandlerWithOptions(pathsToUrls, Options{Status: status}, fallback)
+}
+
+// PrefixHandler works like MapHandler, but also supports keys
+// ending in "/*", which match every path that starts with the
+// key without its "*". The rest of the path is appended to the
+// url the key points to, so with the mapping
+//
+//	"/blog/*": "https://blog.example.com/"
+//
+// a request for /blog/2023/some-post is redirected to
+// https://blog.example.com/2023/some-post.
+//
+// To put the rest of the path elsewhere in the url, write
+// {rest} where it goes. With the mapping
+//
+//	"/old/*": "https://new.example.com/archive/{rest}/index.html"
+//
+// a request for /old/2019/notes is redirected to
+// https://new.example.com/archive/2019/notes/index.html. Every
+// {rest} in the url is replaced, with nothing if the request is
+// for the prefix itself.
+//
+// An exact match always takes priority over a prefix match,
+// and when several prefixes match, the longest one wins. Keys
+// naming the same path once decoded are handled as by
+// MapHandler.
+func PrefixHandler(pathsToUrls map[string]string, fallback http.Handler) http.Handler {
+	return newLenientMapHandler(pathsToUrls, Options{PrefixMatching: true}, fallback)
+}
+
+// MapHandlerWithOptions works like MapHandler, but behaves
+// according to the provided Options. The zero Options value
+// gives the same behavior as MapHandler.
+//
+// An error is returned if the options are invalid, if the
+// paths in the map conflict once normalized by the options, or
+// if the map fails the validation the options ask for.
+func MapHandlerWithOptions(pathsToUrls map[string]string, opts Options, fallback http.Handler) (http.Handler, error) {
+	return UrlsHandler(urlsFromMap(pathsToUrls), opts, fallback)
+}
+
+// UrlsHandler works like MapHandlerWithOptions, but takes the
+// mappings as ShortenedUrls, such as ones decoded from a config
+// format this package has no handler for. Like the handlers for
+// YAML and JSON, it fails if a path points to two different
+// urls.
+func UrlsHandler(urls ShortenedUrls, opts Options, fallback http.Handler) (http.Handler, error) {
+	handler, err := newMapHandler(urls, opts, fallback)
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// newLenientMapHandler returns a handler serving pathsToUrls
+// with opts, which must be valid, without ever failing: keys
+// naming the same path once decoded do not conflict, and the
+// one sorting last wins, so the result does not depend on the
+// order of the map.
+func newLenientMapHandler(pathsToUrls map[string]string, opts Options, fallback http.Handler) *pathRedirector {
+	opts, _ = opts.withDefaults()
 	return &pathRedirector{
-		pathsToUrls: pathsToUrls,
-		fallback: fallback,
+		redirector: redirector{opts: opts, fallback: fallback},
+		entries:    opts.mapEntries(pathsToUrls),
 	}
 }
 
+// mapEntries turns pathsToUrls into entries keyed by their
+// normalized path, the key sorting last winning when several
+// end up under the same one.
+func (o Options) mapEntries(pathsToUrls map[string]string) map[string]ShortenedUrl {
+	entries := make(map[string]ShortenedUrl, len(pathsToUrls))
+	for _, entry := range urlsFromMap(pathsToUrls) {
+		entries[o.entryKey(entry.Path)] = entry
+	}
+	return entries
+}
+
+func newMapHandler(urls ShortenedUrls, opts Options, fallback http.Handler) (*pathRedirector, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := opts.buildEntries(urls)
+	if err != nil {
+		return nil, err
+	}
+	return &pathRedirector{
+		redirector: redirector{opts: opts, fallback: fallback},
+		entries:    entries,
+	}, nil
+}
+
 // YAMLHandler will parse the provided YAML and then return
 // an http.HandlerFunc (which also implements http.Handler)
 // that will attempt to map any paths to their corresponding
@@ -57,32 +667,213 @@ func MapHandler(pathsToUrls map[string]string, fallback http.Handler) http.Handl
 //     url: https://www.some-url.com/demo
 //
 // The only errors that can be returned all related to having
-// invalid YAML data.
+// invalid YAML data, or a path that points to two different
+// urls.
+//
+// Like the other handlers parsing config, it also accepts the
+// input compressed with gzip, which is detected from its first
+// bytes and decompressed before parsing.
 //
 // See MapHandler to create a similar http.HandlerFunc via
 // a mapping of paths to urls.
 func YAMLHandler(yamlInput []byte, fallback http.Handler) (http.Handler, error) {
-	parsedYaml, err := parseYAML(yamlInput)
+	handler, _, err := YAMLHandlerWithUrls(yamlInput, fallback)
+	return handler, err
+}
+
+// YAMLHandlerWithUrls works like YAMLHandler, but also returns
+// the ShortenedUrls parsed from the YAML.
+func YAMLHandlerWithUrls(yamlInput []byte, fallback http.Handler) (http.Handler, ShortenedUrls, error) {
+	parsedYaml, err := ParseYAML(yamlInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := UrlsHandler(parsedYaml, Options{}, fallback)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, parsedYaml, nil
+}
+
+func JSONHandler(jsonInput []byte, fallback http.Handler) (http.Handler, error) {
+	handler, _, err := JSONHandlerWithUrls(jsonInput, fallback)
+	return handler, err
+}
+
+// JSONHandlerWithUrls works like JSONHandler, but also returns
+// the ShortenedUrls parsed from the JSON.
+func JSONHandlerWithUrls(jsonInput []byte, fallback http.Handler) (http.Handler, ShortenedUrls, error) {
+	parsedJson, err := ParseJSON(jsonInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := UrlsHandler(parsedJson, Options{}, fallback)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, parsedJson, nil
+}
+
+// JSONMapHandler will parse the provided JSON object and then
+// return an http.Handler that will attempt to map any paths to
+// their corresponding URL. If the path is not provided in the
+// JSON, then the fallback http.Handler will be called instead.
+//
+// JSON is expected to be an object keyed by path:
+//
+//	{"/some-path": "https://www.some-url.com/demo"}
+//
+// The only errors that can be returned all related to having
+// invalid JSON data.
+func JSONMapHandler(jsonInput []byte, fallback http.Handler) (http.Handler, error) {
+	pathMap, err := parseJSONMap(jsonInput)
 	if err != nil {
 		return nil, err
 	}
-	pathMap := buildMap(parsedYaml)
 	return MapHandler(pathMap, fallback), nil
 }
 
-func JSONHandler(jsonInput []byte, fallback http.Handler) (http.Handler, error) {
-	parsedJson, err := parseJSON(jsonInput)
+// YAMLMapHandler will parse the provided YAML mapping and then
+// return an http.Handler that will attempt to map any paths to
+// their corresponding URL. If the path is not provided in the
+// YAML, then the fallback http.Handler will be called instead.
+//
+// YAML is expected to map paths to urls under a top-level paths
+// key, instead of listing entries like YAMLHandler expects.
+// Anchors, aliases and merge keys can be used to share urls:
+//
+//	paths:
+//	  /some-path: &demo https://www.some-url.com/demo
+//	  /other-path: *demo
+//
+// The only errors that can be returned all related to having
+// invalid YAML data, or a document that is not a mapping with a
+// paths key mapping each path to a url. The error says which
+// shape the document has instead.
+func YAMLMapHandler(yml []byte, fallback http.Handler) (http.Handler, error) {
+	pathMap, err := parseYAMLMap(yml)
 	if err != nil {
 		return nil, err
 	}
-	pathMap := buildMap(parsedJson)
 	return MapHandler(pathMap, fallback), nil
 }
 
-func parseYAML(yamlInput []byte) (ShortenedUrls, error) {
+// TOMLHandler will parse the provided TOML and then return
+// an http.Handler that will attempt to map any paths to their
+// corresponding URL. If the path is not provided in the TOML,
+// then the fallback http.Handler will be called instead.
+//
+// TOML is expected to be an array of tables named urls:
+//
+//	[[urls]]
+//	path = "/some-path"
+//	url = "https://www.some-url.com/demo"
+//
+// The only errors that can be returned all related to having
+// invalid TOML data, or a path that points to two different
+// urls.
+func TOMLHandler(tomlInput []byte, fallback http.Handler) (http.Handler, error) {
+	handler, _, err := TOMLHandlerWithUrls(tomlInput, fallback)
+	return handler, err
+}
+
+// TOMLHandlerWithUrls works like TOMLHandler, but also returns
+// the ShortenedUrls parsed from the TOML.
+func TOMLHandlerWithUrls(tomlInput []byte, fallback http.Handler) (http.Handler, ShortenedUrls, error) {
+	parsedToml, err := parseTOML(tomlInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := UrlsHandler(parsedToml, Options{}, fallback)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, parsedToml, nil
+}
+
+// CSVHandler will parse the provided CSV and then return
+// an http.Handler that will attempt to map any paths to their
+// corresponding URL. If the path is not provided in the CSV,
+// then the fallback http.Handler will be called instead.
+//
+// CSV is expected to have two columns and a header row:
+//
+//	path,url
+//	/some-path,https://www.some-url.com/demo
+//
+// The only errors that can be returned all related to having
+// invalid CSV data, including rows without exactly two columns,
+// or a path that points to two different urls.
+func CSVHandler(csvInput []byte, fallback http.Handler) (http.Handler, error) {
+	handler, _, err := CSVHandlerWithUrls(csvInput, fallback)
+	return handler, err
+}
+
+// CSVHandlerWithUrls works like CSVHandler, but also returns
+// the ShortenedUrls parsed from the CSV.
+func CSVHandlerWithUrls(csvInput []byte, fallback http.Handler) (http.Handler, ShortenedUrls, error) {
+	parsedCsv, err := parseCSV(csvInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := UrlsHandler(parsedCsv, Options{}, fallback)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, parsedCsv, nil
+}
+
+// XMLHandler will parse the provided XML and then return
+// an http.Handler that will attempt to map any paths to their
+// corresponding URL. If the path is not provided in the XML,
+// then the fallback http.Handler will be called instead.
+//
+// XML is expected to be in the format:
+//
+//	<urls>
+//	  <url>
+//	    <path>/some-path</path>
+//	    <url>https://www.some-url.com/demo</url>
+//	  </url>
+//	</urls>
+//
+// The only errors that can be returned all related to having
+// invalid XML data, or a path that points to two different
+// urls.
+func XMLHandler(xmlInput []byte, fallback http.Handler) (http.Handler, error) {
+	handler, _, err := XMLHandlerWithUrls(xmlInput, fallback)
+	return handler, err
+}
+
+// XMLHandlerWithUrls works like XMLHandler, but also returns
+// the ShortenedUrls parsed from the XML.
+func XMLHandlerWithUrls(xmlInput []byte, fallback http.Handler) (http.Handler, ShortenedUrls, error) {
+	parsedXml, err := parseXML(xmlInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := UrlsHandler(parsedXml, Options{}, fallback)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, parsedXml, nil
+}
+
+// ParseYAML parses a list of mappings in the format read by
+// YAMLHandler, without building a handler, so that tools can
+// work on the same entries the handlers would serve. Input
+// compressed with gzip is decompressed first.
+func ParseYAML(yamlInput []byte) (ShortenedUrls, error) {
+	yamlInput, err := prepareInput(yamlInput)
+	if err != nil {
+		return nil, err
+	}
+
 	var urls ShortenedUrls
 
-	err := yaml.Unmarshal(yamlInput, &urls)
+	err = decodeSafely("yaml", func() error {
+		return yaml.Unmarshal(yamlInput, &urls)
+	})
 	if err != nil {
 		slog.Error("Error: " + err.Error())
 		return nil, err
@@ -91,22 +882,279 @@ func parseYAML(yamlInput []byte) (ShortenedUrls, error) {
 	return urls, nil
 }
 
-func parseJSON(jsonInput []byte) (ShortenedUrls, error) {
+// ParseJSON works like ParseYAML, but parses the format read
+// by JSONHandler.
+func ParseJSON(jsonInput []byte) (ShortenedUrls, error) {
+	jsonInput, err := prepareInput(jsonInput)
+	if err != nil {
+		return nil, err
+	}
+
 	var urls ShortenedUrls
 
-	err := json.Unmarshal(jsonInput, &urls)
+	err = decodeSafely("json", func() error {
+		return json.Unmarshal(jsonInput, &urls)
+	})
+	if err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+func parseJSONMap(jsonInput []byte) (map[string]string, error) {
+	jsonInput, err := prepareInput(jsonInput)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathsToUrls map[string]string
+
+	err = json.Unmarshal(jsonInput, &pathsToUrls)
+	if err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	return pathsToUrls, nil
+}
+
+func parseYAMLMap(yamlInput []byte) (map[string]string, error) {
+	yamlInput, err := prepareInput(yamlInput)
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		Paths map[string]string `yaml:"paths"`
+	}
+
+	err = decodeSafely("yaml", func() error {
+		var root yaml.Node
+		if err := yaml.Unmarshal(yamlInput, &root); err != nil {
+			return err
+		}
+		if err := yamlMapShape(&root); err != nil {
+			return err
+		}
+		return root.Decode(&document)
+	})
+	if err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	return document.Paths, nil
+}
+
+// yamlMapShape checks that root is a document holding a mapping
+// with a paths key whose value is a mapping, and describes what
+// it found otherwise.
+func yamlMapShape(root *yaml.Node) error {
+	if len(root.Content) == 0 {
+		return errors.New("yaml: empty document, expected a mapping with a paths key")
+	}
+	top := root.Content[0]
+	if top.Kind == yaml.SequenceNode {
+		return errors.New("yaml: found a list of entries, expected a mapping with a paths key (use YAMLHandler for lists)")
+	}
+	if top.Kind != yaml.MappingNode {
+		return fmt.Errorf("yaml: line %d: expected a mapping with a paths key", top.Line)
+	}
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		if top.Content[i].Value != "paths" {
+			continue
+		}
+		paths := top.Content[i+1]
+		if paths.Kind == yaml.AliasNode {
+			paths = paths.Alias
+		}
+		if paths.Kind != yaml.MappingNode {
+			return fmt.Errorf("yaml: line %d: expected paths to map each path to a url", paths.Line)
+		}
+		return nil
+	}
+	return fmt.Errorf("yaml: line %d: mapping has no paths key", top.Line)
+}
+
+func parseTOML(tomlInput []byte) (ShortenedUrls, error) {
+	tomlInput, err := prepareInput(tomlInput)
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		Urls ShortenedUrls `toml:"urls"`
+	}
+
+	err = decodeSafely("toml", func() error {
+		return toml.Unmarshal(tomlInput, &document)
+	})
+	if err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	return document.Urls, nil
+}
+
+func parseCSV(csvInput []byte) (ShortenedUrls, error) {
+	csvInput, err := prepareInput(csvInput)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(csvInput))
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
 	if err != nil {
 		slog.Error("Error: " + err.Error())
 		return nil, err
 	}
 
+	var urls ShortenedUrls
+	for i, record := range records {
+		if i == 0 {
+			continue
+		}
+		if len(record) != 2 {
+			err := fmt.Errorf("csv row %d: expected 2 columns, got %d", i+1, len(record))
+			slog.Error("Error: " + err.Error())
+			return nil, err
+		}
+		urls = append(urls, ShortenedUrl{
+			Path: strings.TrimSpace(record[0]),
+			Url:  strings.TrimSpace(record[1]),
+		})
+	}
+
 	return urls, nil
 }
 
-func buildMap(urls ShortenedUrls) map[string]string {
-	pathsToUrls := map[string]string{}
+// BuildMap turns urls into a map of paths to urls, such as the
+// one MapHandler takes. When a path appears more than once, the
+// last url wins. Aliases in Paths are included, and disabled
+// entries are left out. Paths are kept as they are, without the
+// normalization a handler applies.
+func BuildMap(urls ShortenedUrls) map[string]string {
+	pathsToUrls := make(map[string]string, len(urls))
 	for _, url := range urls {
-		pathsToUrls[url.Path] = url.Url
+		if !url.IsEnabled() {
+			continue
+		}
+		for _, path := range entryPaths(url) {
+			pathsToUrls[path] = url.Url
+		}
 	}
 	return pathsToUrls
 }
+
+func parseXML(xmlInput []byte) (ShortenedUrls, error) {
+	xmlInput, err := prepareInput(xmlInput)
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		XMLName xml.Name      `xml:"urls"`
+		Urls    ShortenedUrls `xml:"url"`
+	}
+
+	err = xml.Unmarshal(xmlInput, &document)
+	if err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	return document.Urls, nil
+}
+
+// decodeSafely runs decode, turning a panic inside it into an
+// error. Third-party decoders have panicked on malformed input
+// before, and a bad config should never crash the caller.
+func decodeSafely(format string, decode func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: malformed input: %v", format, r)
+		}
+	}()
+	return decode()
+}
+
+// restPlaceholder marks where the rest of the path matched by
+// a prefix key goes in its url.
+const restPlaceholder = "{rest}"
+
+// joinPath puts rest in place of every {rest} placeholder in
+// url, or, if there is none, appends rest to the path of url,
+// keeping any query and fragment after it.
+func joinPath(url, rest string) string {
+	if strings.Contains(url, restPlaceholder) {
+		return strings.ReplaceAll(url, restPlaceholder, rest)
+	}
+	if rest == "" {
+		return url
+	}
+	end := strings.IndexAny(url, "?#")
+	if end < 0 {
+		end = len(url)
+	}
+	base, suffix := url[:end], url[end:]
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return base + rest + suffix
+}
+
+// ReverseMap returns a map from each url in urls to the paths
+// that point to it. Several paths can point to the same url, so
+// the paths are kept in the order they appear in urls, each
+// alias in Paths right after the Path of its entry. A path
+// listed twice for the same url is only included once.
+//
+// It can be built from the same ShortenedUrls passed to
+// UrlsHandler to answer which short paths lead to a url.
+func ReverseMap(urls ShortenedUrls) map[string][]string {
+	urlsToPaths := map[string][]string{}
+	for _, url := range urls {
+		for _, path := range entryPaths(url) {
+			if slices.Contains(urlsToPaths[url.Url], path) {
+				continue
+			}
+			urlsToPaths[url.Url] = append(urlsToPaths[url.Url], path)
+		}
+	}
+	return urlsToPaths
+}
+
+// ReverseEntries works like ReverseMap, but maps each url to the
+// full entries that point to it, so that details such as their
+// Description are kept. An entry listed twice for the same url,
+// with the same Path and Paths, is only included once.
+func ReverseEntries(urls ShortenedUrls) map[string]ShortenedUrls {
+	urlsToEntries := map[string]ShortenedUrls{}
+	for _, url := range urls {
+		if slices.ContainsFunc(urlsToEntries[url.Url], func(entry ShortenedUrl) bool {
+			return slices.Equal(entryPaths(entry), entryPaths(url))
+		}) {
+			continue
+		}
+		urlsToEntries[url.Url] = append(urlsToEntries[url.Url], url)
+	}
+	return urlsToEntries
+}
+
+// urlsFromMap turns a map of paths to urls into ShortenedUrls,
+// sorted by path.
+func urlsFromMap(pathsToUrls map[string]string) ShortenedUrls {
+	urls := make(ShortenedUrls, 0, len(pathsToUrls))
+	for path, url := range pathsToUrls {
+		urls = append(urls, ShortenedUrl{Path: path, Url: url})
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		return urls[i].Path < urls[j].Path
+	})
+	return urls
+}
@@ -0,0 +1,299 @@
+package urlshort
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the logs of handlers built without a Logger, and of
+	// config errors, out of the test output.
+	slog.SetDefault(discardLogger)
+	os.Exit(m.Run())
+}
+
+// redirectCase is a request and the status and Location header
+// a handler is expected to answer it with.
+type redirectCase struct {
+	name     string
+	method   string
+	target   string
+	header   http.Header
+	status   int
+	location string
+}
+
+// checkRedirects serves each case with handler, as a GET unless
+// the case names another method, and checks the answer.
+func checkRedirects(t *testing.T, handler http.Handler, cases []redirectCase) {
+	t.Helper()
+	for _, tc := range cases {
+		name := tc.name
+		if name == "" {
+			name = tc.target
+		}
+		t.Run(name, func(t *testing.T) {
+			rec := serveCase(handler, tc)
+			if rec.Code != tc.status {
+				t.Errorf("status = %d, want %d", rec.Code, tc.status)
+			}
+			if got := rec.Header().Get("Location"); got != tc.location {
+				t.Errorf("Location = %q, want %q", got, tc.location)
+			}
+		})
+	}
+}
+
+func serveCase(handler http.Handler, tc redirectCase) *httptest.ResponseRecorder {
+	method := tc.method
+	if method == "" {
+		method = http.MethodGet
+	}
+	r := httptest.NewRequest(method, tc.target, nil)
+	for name, values := range tc.header {
+		r.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestMapHandlersResolveDecodedCollisions(t *testing.T) {
+	pathsToUrls := map[string]string{
+		"/caf%C3%A9": "https://first.example.com",
+		"/café":      "https://second.example.com",
+	}
+	fallback := http.NotFoundHandler()
+	handlers := map[string]http.Handler{
+		"MapHandler":           MapHandler(pathsToUrls, fallback),
+		"PrefixHandler":        PrefixHandler(pathsToUrls, fallback),
+		"MapHandlerOr404":      MapHandlerOr404(pathsToUrls, "not found"),
+		"NewCountingHandler":   NewCountingHandler(pathsToUrls, fallback),
+		"NewLastAccessHandler": NewLastAccessHandler(pathsToUrls, fallback),
+	}
+	for name, handler := range handlers {
+		t.Run(name, func(t *testing.T) {
+			if handler == nil {
+				t.Fatal("got nil handler")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/caf%C3%A9", nil))
+			if rec.Code != http.StatusMovedPermanently {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusMovedPermanently)
+			}
+			if got, want := rec.Header().Get("Location"), "https://second.example.com"; got != want {
+				t.Errorf("Location = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func FuzzParseYAML(f *testing.F) {
+	f.Add([]byte("- path: /urlshort\n  url: https://github.com/gophercises/urlshort\n"))
+	f.Add([]byte("- path: /old\n  url: https://example.com\n  status: 302\n  aliases: [/older]\n"))
+	f.Add([]byte("- path: /a\n  targets: [https://a.example.com, https://b.example.com]\n"))
+	f.Add([]byte("\xef\xbb\xbf- path: /bom\n  url: https://example.com\n"))
+	f.Add([]byte("[]"))
+	f.Add([]byte(""))
+	f.Fuzz(func(t *testing.T, input []byte) {
+		urls, err := ParseYAML(input)
+		if err != nil {
+			return
+		}
+		// Whatever parses must build or fail cleanly.
+		UrlsHandler(urls, Options{}, http.NotFoundHandler())
+	})
+}
+
+func FuzzParseJSON(f *testing.F) {
+	f.Add([]byte(`[{"path": "/urlshort", "url": "https://github.com/gophercises/urlshort"}]`))
+	f.Add([]byte(`[{"path": "/old", "url": "https://example.com", "status": 302, "aliases": ["/older"]}]`))
+	f.Add([]byte(`[{"path": "/a", "targets": ["https://a.example.com", "https://b.example.com"]}]`))
+	f.Add([]byte(`{"/short": "https://example.com"}`))
+	f.Add([]byte(`[]`))
+	f.Add([]byte(``))
+	f.Fuzz(func(t *testing.T, input []byte) {
+		urls, err := ParseJSON(input)
+		if err != nil {
+			return
+		}
+		UrlsHandler(urls, Options{}, http.NotFoundHandler())
+	})
+}
+
+func FuzzParseProto(f *testing.F) {
+	entry := protoMessage{}.string(1, "/urlshort").string(2, "https://github.com/gophercises/urlshort")
+	f.Add([]byte(protoMessage{}.message(1, entry)))
+	f.Add([]byte(protoMessage{}.message(1, protoMessage{}.string(1, "/old").varint(6, 302).string(3, "/older"))))
+	f.Add([]byte(protoMessage{}.message(1, protoMessage{}.mapEntry(11, "utm", "abc").varint(99, 1))))
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, input []byte) {
+		urls, err := parseProto(input)
+		if err != nil {
+			return
+		}
+		UrlsHandler(urls, Options{}, http.NotFoundHandler())
+	})
+}
+
+func TestExpiresAt(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	expiry := now.Add(time.Hour)
+	urls := ShortenedUrls{
+		{Path: "/campaign", Url: "https://example.com/campaign", ExpiresAt: &expiry},
+		{Path: "/forever", Url: "https://example.com/forever"},
+	}
+	clock := now
+	opts := Options{Now: func() time.Time { return clock }, Logger: discardLogger}
+	handler, err := UrlsHandler(urls, opts, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRedirects(t, handler, []redirectCase{
+		{name: "before expiry", target: "/campaign", status: http.StatusMovedPermanently, location: "https://example.com/campaign"},
+		{name: "no expiry", target: "/forever", status: http.StatusMovedPermanently, location: "https://example.com/forever"},
+	})
+	clock = expiry
+	checkRedirects(t, handler, []redirectCase{
+		{name: "at expiry", target: "/campaign", status: http.StatusNotFound},
+		{name: "no expiry later", target: "/forever", status: http.StatusMovedPermanently, location: "https://example.com/forever"},
+	})
+}
+
+func TestHeadRequestsHaveNoBody(t *testing.T) {
+	urls := ShortenedUrls{
+		{Path: "/a", Url: "https://example.com/a"},
+		{Path: "/old", Gone: true},
+	}
+	handler, err := UrlsHandler(urls, Options{Logger: discardLogger}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		redirectCase
+		wantBody bool
+	}{
+		{redirectCase{name: "GET redirect", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"}, true},
+		{redirectCase{name: "HEAD redirect", method: http.MethodHead, target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"}, false},
+		{redirectCase{name: "GET gone", target: "/old", status: http.StatusGone}, true},
+		{redirectCase{name: "HEAD gone", method: http.MethodHead, target: "/old", status: http.StatusGone}, false},
+	}
+	for _, tt := range tests {
+		checkRedirects(t, handler, []redirectCase{tt.redirectCase})
+		if body := serveCase(handler, tt.redirectCase).Body.Len(); (body > 0) != tt.wantBody {
+			t.Errorf("%s: got a body of %d bytes, want a body: %t", tt.name, body, tt.wantBody)
+		}
+	}
+}
+
+func TestPrefixHandlerRest(t *testing.T) {
+	handler := PrefixHandler(map[string]string{
+		"/blog/*":     "https://blog.example.com/",
+		"/old/*":      "https://new.example.com/archive/{rest}/index.html",
+		"/blog/about": "https://example.com/about",
+	}, http.NotFoundHandler())
+	checkRedirects(t, handler, []redirectCase{
+		{name: "appended", target: "/blog/2023/some-post", status: http.StatusMovedPermanently, location: "https://blog.example.com/2023/some-post"},
+		{name: "placeholder", target: "/old/2019/post", status: http.StatusMovedPermanently, location: "https://new.example.com/archive/2019/post/index.html"},
+		{name: "exact wins", target: "/blog/about", status: http.StatusMovedPermanently, location: "https://example.com/about"},
+		{name: "miss", target: "/other", status: http.StatusNotFound},
+	})
+}
+
+func TestOptionsRequests(t *testing.T) {
+	urls := ShortenedUrls{
+		{Path: "/any", Url: "https://example.com/any"},
+		{Path: "/get", Url: "https://example.com/get", Methods: []string{http.MethodGet, http.MethodHead}},
+	}
+	handler, err := UrlsHandler(urls, Options{Logger: discardLogger}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		redirectCase
+		allow string
+	}{
+		{redirectCase{name: "any method", method: http.MethodOptions, target: "/any", status: http.StatusNoContent}, "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS"},
+		{redirectCase{name: "restricted", method: http.MethodOptions, target: "/get", status: http.StatusNoContent}, "GET, HEAD, OPTIONS"},
+		{redirectCase{name: "miss", method: http.MethodOptions, target: "/missing", status: http.StatusNotFound}, ""},
+		{redirectCase{name: "not allowed", method: http.MethodPost, target: "/get", status: http.StatusMethodNotAllowed}, "GET, HEAD"},
+	}
+	for _, tt := range tests {
+		checkRedirects(t, handler, []redirectCase{tt.redirectCase})
+		if got := serveCase(handler, tt.redirectCase).Header().Get("Allow"); got != tt.allow {
+			t.Errorf("%s: Allow = %q, want %q", tt.name, got, tt.allow)
+		}
+	}
+}
+
+func TestRedirectStatusesAndMethods(t *testing.T) {
+	urls := ShortenedUrls{
+		{Path: "/301", Url: "/target", Status: http.StatusMovedPermanently},
+		{Path: "/302", Url: "/target", Status: http.StatusFound},
+		{Path: "/307", Url: "/target", Status: http.StatusTemporaryRedirect},
+		{Path: "/308", Url: "/target", Status: http.StatusPermanentRedirect},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/target", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		io.WriteString(w, r.Method+" "+string(body))
+	})
+	handler, err := UrlsHandler(urls, Options{Logger: discardLogger}, mux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{method: http.MethodPost, target: "/301", status: http.StatusMovedPermanently, location: "/target"},
+		{method: http.MethodPost, target: "/302", status: http.StatusFound, location: "/target"},
+		{method: http.MethodPost, target: "/307", status: http.StatusTemporaryRedirect, location: "/target"},
+		{method: http.MethodPost, target: "/308", status: http.StatusPermanentRedirect, location: "/target"},
+	})
+
+	// Follow the redirects with a real client, which is what
+	// decides whether the method and body survive.
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/301", "GET "},
+		{"/302", "GET "},
+		{"/307", "POST payload"},
+		{"/308", "POST payload"},
+	}
+	for _, tt := range tests {
+		resp, err := server.Client().Post(server.URL+tt.path, "text/plain", strings.NewReader("payload"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(got) != tt.want {
+			t.Errorf("POST %s: target got %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestReverseMapAliases(t *testing.T) {
+	urls := ShortenedUrls{
+		{Path: "/gh", Paths: []string{"/github"}, Url: "https://github.com"},
+		{Paths: []string{"/hub"}, Url: "https://github.com"},
+		{Path: "/gh", Paths: []string{"/github"}, Url: "https://github.com"},
+	}
+	want := []string{"/gh", "/github", "/hub"}
+	if got := ReverseMap(urls)["https://github.com"]; !slices.Equal(got, want) {
+		t.Errorf("ReverseMap() = %v, want %v", got, want)
+	}
+	if got := ReverseEntries(urls)["https://github.com"]; len(got) != 2 {
+		t.Errorf("ReverseEntries() = %+v, want 2 entries", got)
+	}
+}
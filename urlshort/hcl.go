@@ -0,0 +1,72 @@
+package urlshort
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// HCLHandler will parse the provided HCL and then return an
+// http.Handler that will attempt to map any paths to their
+// corresponding URL. If the path is not provided in the HCL,
+// then the fallback http.Handler will be called instead.
+//
+// HCL is expected to have one url block per mapping, with the
+// same attributes as the fields JSONHandler reads, except for
+// expires_at and not_before, and a nested target block per
+// weighted target:
+//
+//	url {
+//	  path = "/some-path"
+//	  url  = "https://www.some-url.com/demo"
+//	}
+//
+// The only errors that can be returned all related to having
+// invalid HCL data, or a path that points to two different
+// urls. Errors wrap the HCL diagnostics, which name the file
+// position of each problem.
+func HCLHandler(hclInput []byte, fallback http.Handler) (http.Handler, error) {
+	handler, _, err := HCLHandlerWithUrls(hclInput, fallback)
+	return handler, err
+}
+
+// HCLHandlerWithUrls works like HCLHandler, but also returns
+// the ShortenedUrls parsed from the HCL.
+func HCLHandlerWithUrls(hclInput []byte, fallback http.Handler) (http.Handler, ShortenedUrls, error) {
+	parsedHcl, err := parseHCL(hclInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := UrlsHandler(parsedHcl, Options{}, fallback)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, parsedHcl, nil
+}
+
+func parseHCL(hclInput []byte) (ShortenedUrls, error) {
+	hclInput, err := prepareInput(hclInput)
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		Urls ShortenedUrls `hcl:"url,block"`
+	}
+
+	file, diags := hclsyntax.ParseConfig(hclInput, "config.hcl", hcl.InitialPos)
+	if !diags.HasErrors() {
+		diags = append(diags, gohcl.DecodeBody(file.Body, nil, &document)...)
+	}
+	if diags.HasErrors() {
+		err := fmt.Errorf("hcl: %w", diags)
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	return document.Urls, nil
+}
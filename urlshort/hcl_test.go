@@ -0,0 +1,66 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHCLHandler(t *testing.T) {
+	handler, urls, err := HCLHandlerWithUrls([]byte(`
+url {
+  path = "/a"
+  url  = "https://example.com/a"
+}
+
+url {
+  path   = "/old"
+  paths  = ["/older"]
+  url    = "https://example.com/old"
+  status = 302
+}
+
+url {
+  path = "/gone"
+  gone = true
+}
+
+url {
+  path = "/w"
+  target {
+    url    = "https://example.com/w"
+    weight = 1
+  }
+}
+`), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 4 {
+		t.Errorf("parsed %d urls, want 4", len(urls))
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "status", target: "/old", status: http.StatusFound, location: "https://example.com/old"},
+		{name: "alias", target: "/older", status: http.StatusFound, location: "https://example.com/old"},
+		{name: "gone", target: "/gone", status: http.StatusGone},
+		{name: "target", target: "/w", status: http.StatusMovedPermanently, location: "https://example.com/w"},
+		{name: "miss", target: "/b", status: http.StatusNotFound},
+	})
+}
+
+func TestHCLHandlerErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, input string
+	}{
+		{name: "syntax", input: `url {`},
+		{name: "unknown attribute", input: "url {\n  path = \"/a\"\n  link = \"https://example.com\"\n}\n"},
+		{name: "wrong type", input: "url {\n  path = \"/a\"\n  status = \"moved\"\n}\n"},
+		{name: "duplicate", input: "url {\n  path = \"/a\"\n  url = \"https://example.com/1\"\n}\nurl {\n  path = \"/a\"\n  url = \"https://example.com/2\"\n}\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := HCLHandler([]byte(tc.input), http.NotFoundHandler()); err == nil {
+				t.Error("HCLHandler() = nil error")
+			}
+		})
+	}
+}
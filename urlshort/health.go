@@ -0,0 +1,144 @@
+package urlshort
+
+import (
+	"context"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// Pinger is implemented by handlers that can report whether
+// the store they read redirects from is reachable, for use in
+// health and readiness checks. Every handler in this package
+// implements it. Handlers serving from memory always report
+// success.
+type Pinger interface {
+	// Ping checks the store, returning an error if it cannot be
+	// used.
+	Ping(ctx context.Context) error
+}
+
+// Ping implements Pinger.
+func (pr *pathRedirector) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (pr *patternRedirector) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (hr *hostRedirector) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (rr *reloadableRedirector) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (sr *sliceRedirector) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (qr *queryRedirector) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (rr *regexRedirector) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (fr *funcRedirector) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (sr *starlarkRedirector) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (mh *MutableHandler) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (ah *AtomicMapHandler) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (fh *FileHandler) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (rh *RemoteConfigHandler) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (ch *CountingHandler) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger.
+func (lh *LastAccessHandler) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements Pinger by sending a PING to Redis.
+func (rr *redisRedirector) Ping(ctx context.Context) error {
+	return rr.client.Ping(ctx).Err()
+}
+
+// Ping implements Pinger by checking that the database
+// connection is alive.
+func (dr *dbRedirector) Ping(ctx context.Context) error {
+	return dr.db.PingContext(ctx)
+}
+
+// Ping implements Pinger by checking that the database
+// connection is alive.
+func (sh *SQLHandler) Ping(ctx context.Context) error {
+	return sh.db.PingContext(ctx)
+}
+
+// Ping implements Pinger by checking that the bucket can still
+// be read.
+func (br *boltRedirector) Ping(ctx context.Context) error {
+	return br.db.View(func(tx *bbolt.Tx) error {
+		if tx.Bucket(br.bucket) == nil {
+			return fmt.Errorf("bucket '%s' not found", br.bucket)
+		}
+		return nil
+	})
+}
+
+// Ping implements Pinger, checking every handler in the chain
+// that implements it.
+func (ch *chainHandler) Ping(ctx context.Context) error {
+	for _, handler := range ch.handlers {
+		if pinger, ok := handler.(Pinger); ok {
+			if err := pinger.Ping(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Ping implements Pinger by calling the Ping method of the
+// store, if it has one.
+func (sr *storeRedirector) Ping(ctx context.Context) error {
+	if pinger, ok := sr.store.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package urlshort
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+type hostRedirector struct {
+	redirector
+	hosts map[string]map[string]ShortenedUrl
+}
+
+// HostHandler works like MapHandler, but keys the mappings on
+// the host of the request as well as its path, so that one
+// server can give different meanings to short.example.com/x and
+// go.example.com/x. The outer map is keyed by host, and the
+// inner maps work like the one given to MapHandler.
+//
+// The mappings under the empty host "" apply to every host. A
+// request is first looked up in the mappings of its host and,
+// if its host has none or none match, in those of "". If
+// neither matches, then the fallback http.Handler will be
+// called instead.
+//
+// A host key starting with "*." is a wildcard matching every
+// subdomain of the rest of the key, at any depth, but not that
+// domain itself, so "*.example.com" matches a.example.com and
+// a.b.example.com, but not example.com. A request is looked up
+// in the mappings of its exact host first, then in those of
+// the wildcards matching it, from the most specific one, such
+// as "*.b.example.com", to the least, and last in those of "".
+//
+// Hosts are normalized before matching, both in the map and in
+// requests: any port is removed, letters are lowercased and a
+// trailing dot is dropped, so "Go.Example.com:8080" matches a
+// "go.example.com" key.
+func HostHandler(hostsToPaths map[string]map[string]string, fallback http.Handler) (http.Handler, error) {
+	opts, _ := Options{}.withDefaults()
+	hr := &hostRedirector{
+		redirector: redirector{opts: opts, fallback: fallback},
+		hosts:      make(map[string]map[string]ShortenedUrl, len(hostsToPaths)),
+	}
+	for host, pathsToUrls := range hostsToPaths {
+		entries, err := opts.buildEntries(urlsFromMap(pathsToUrls))
+		if err != nil {
+			return nil, err
+		}
+		hr.hosts[normalizeHost(host)] = entries
+	}
+	return hr, nil
+}
+
+// normalizeHost removes any port and trailing dot from host and
+// lowercases it.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+func (hr *hostRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	hr.serve(w, r, hr.lookup(r.Host))
+}
+
+// Match implements Matcher.
+func (hr *hostRedirector) Match(r *http.Request) (string, bool) {
+	return hr.matchRequest(r, hr.lookup(r.Host))
+}
+
+func (hr *hostRedirector) lookup(host string) lookupFunc {
+	hosts := hostCandidates(normalizeHost(host))
+	return func(key string) (ShortenedUrl, bool) {
+		for _, host := range hosts {
+			if entry, exists := hr.hosts[host][key]; exists {
+				return entry, true
+			}
+		}
+		return ShortenedUrl{}, false
+	}
+}
+
+// hostCandidates returns the keys to look host up under, from
+// the most specific to the least: host itself, the wildcards
+// matching it, and "".
+func hostCandidates(host string) []string {
+	candidates := []string{host}
+	for domain := host; ; {
+		_, parent, found := strings.Cut(domain, ".")
+		if !found || parent == "" {
+			break
+		}
+		candidates = append(candidates, "*."+parent)
+		domain = parent
+	}
+	if host != "" {
+		candidates = append(candidates, "")
+	}
+	return candidates
+}
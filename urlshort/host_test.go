@@ -0,0 +1,27 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHostHandlerWildcards(t *testing.T) {
+	handler, err := HostHandler(map[string]map[string]string{
+		"go.example.com":  {"/x": "https://exact.example.org/x"},
+		"*.example.com":   {"/x": "https://wild.example.org/x", "/y": "https://wild.example.org/y"},
+		"*.b.example.com": {"/x": "https://deep.example.org/x"},
+		"":                {"/z": "https://any.example.org/z"},
+	}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "exact", target: "http://go.example.com/x", status: http.StatusMovedPermanently, location: "https://exact.example.org/x"},
+		{name: "normalized", target: "http://Go.Example.com.:8080/x", status: http.StatusMovedPermanently, location: "https://exact.example.org/x"},
+		{name: "exact falls to wildcard", target: "http://go.example.com/y", status: http.StatusMovedPermanently, location: "https://wild.example.org/y"},
+		{name: "wildcard", target: "http://a.example.com/x", status: http.StatusMovedPermanently, location: "https://wild.example.org/x"},
+		{name: "most specific wildcard", target: "http://a.b.example.com/x", status: http.StatusMovedPermanently, location: "https://deep.example.org/x"},
+		{name: "wildcard skips its domain", target: "http://example.com/x", status: http.StatusNotFound},
+		{name: "every host", target: "http://other.org/z", status: http.StatusMovedPermanently, location: "https://any.example.org/z"},
+	})
+}
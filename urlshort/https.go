@@ -0,0 +1,65 @@
+package urlshort
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// HTTPSHandler returns an http.Handler that redirects requests
+// made over plain http to the same url over https, and passes
+// requests already made over https to next. It can wrap any
+// handler in this package, so that a short link requested over
+// http is first upgraded, and then redirected by next once the
+// client comes back over https. Requests are never redirected
+// twice by it, as https requests go straight to next.
+//
+// The upgrade uses a 308 Permanent Redirect, so that clients
+// keep the method and body of the request. Any port in the
+// Host of the request is dropped, since it is the one plain
+// http was served on, so clients come back on the default
+// https port, 443.
+//
+// A request counts as https when it arrived over TLS. When
+// trustProxy is true, the X-Forwarded-Proto header is also
+// used, which is needed behind a proxy or load balancer that
+// terminates TLS, as every request then reaches the server over
+// plain http. Only set trustProxy when such a proxy sets the
+// header on every request, since clients can send any value
+// for it themselves.
+func HTTPSHandler(next http.Handler, trustProxy bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isHTTPS(r, trustProxy) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		http.Redirect(w, r, "https://"+withoutPort(r.Host)+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// withoutPort returns host without its port, if it has one,
+// keeping the brackets around an IPv6 address.
+func withoutPort(host string) string {
+	hostname, _, err := net.SplitHostPort(host)
+	if err != nil {
+		return host
+	}
+	if strings.Contains(hostname, ":") {
+		return "[" + hostname + "]"
+	}
+	return hostname
+}
+
+// isHTTPS reports whether r was made over https.
+func isHTTPS(r *http.Request, trustProxy bool) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if !trustProxy {
+		return false
+	}
+	// Proxies chained together may each append their own value,
+	// and the first one is what the client used.
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
@@ -0,0 +1,48 @@
+package urlshort
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPSHandler(t *testing.T) {
+	next := MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	for _, tc := range []struct {
+		name       string
+		trustProxy bool
+		target     string
+		tls        bool
+		proto      string
+		status     int
+		location   string
+	}{
+		{name: "upgraded", target: "http://short.example.com/a?x=1", status: http.StatusPermanentRedirect, location: "https://short.example.com/a?x=1"},
+		{name: "port dropped", target: "http://short.example.com:8080/a", status: http.StatusPermanentRedirect, location: "https://short.example.com/a"},
+		{name: "ipv6 port dropped", target: "http://[2001:db8::1]:8080/a", status: http.StatusPermanentRedirect, location: "https://[2001:db8::1]/a"},
+		{name: "tls", target: "https://short.example.com/a", tls: true, status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "proxy header ignored", target: "http://short.example.com/a", proto: "https", status: http.StatusPermanentRedirect, location: "https://short.example.com/a"},
+		{name: "trusted proxy", trustProxy: true, target: "http://short.example.com/a", proto: "HTTPS", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "first proxy wins", trustProxy: true, target: "http://short.example.com/a", proto: "http, https", status: http.StatusPermanentRedirect, location: "https://short.example.com/a"},
+		{name: "trusted proxy over http", trustProxy: true, target: "http://short.example.com/b", proto: "http", status: http.StatusPermanentRedirect, location: "https://short.example.com/b"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tc.target, nil)
+			if tc.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			if tc.proto != "" {
+				r.Header.Set("X-Forwarded-Proto", tc.proto)
+			}
+			rr := httptest.NewRecorder()
+			HTTPSHandler(next, tc.trustProxy).ServeHTTP(rr, r)
+			if rr.Code != tc.status {
+				t.Errorf("status = %d, want %d", rr.Code, tc.status)
+			}
+			if got := rr.Header().Get("Location"); got != tc.location {
+				t.Errorf("Location = %q, want %q", got, tc.location)
+			}
+		})
+	}
+}
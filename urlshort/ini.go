@@ -0,0 +1,76 @@
+package urlshort
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// INIHandler will parse the provided properties file and then
+// return an http.Handler that will attempt to map any paths to
+// their corresponding URL. If the path is not provided in the
+// file, then the fallback http.Handler will be called instead.
+//
+// The file is expected to have one mapping per line, with the
+// path and the url separated by the first "=":
+//
+//	# comments and blank lines are ignored
+//	/some-path = https://www.some-url.com/demo
+//
+// The only errors that can be returned all related to having
+// lines without a "=", or a path that points to two different
+// urls. Errors name the offending line number.
+func INIHandler(iniInput []byte, fallback http.Handler) (http.Handler, error) {
+	handler, _, err := INIHandlerWithUrls(iniInput, fallback)
+	return handler, err
+}
+
+// INIHandlerWithUrls works like INIHandler, but also returns
+// the ShortenedUrls parsed from the file.
+func INIHandlerWithUrls(iniInput []byte, fallback http.Handler) (http.Handler, ShortenedUrls, error) {
+	parsedIni, err := parseINI(iniInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := UrlsHandler(parsedIni, Options{}, fallback)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, parsedIni, nil
+}
+
+func parseINI(iniInput []byte) (ShortenedUrls, error) {
+	iniInput, err := prepareInput(iniInput)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls ShortenedUrls
+
+	scanner := bufio.NewScanner(bytes.NewReader(iniInput))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		path, url, found := strings.Cut(text, "=")
+		if !found {
+			err := fmt.Errorf("line %d: missing '=' separator", line)
+			slog.Error("Error: " + err.Error())
+			return nil, err
+		}
+		urls = append(urls, ShortenedUrl{
+			Path: strings.TrimSpace(path),
+			Url:  strings.TrimSpace(url),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	return urls, nil
+}
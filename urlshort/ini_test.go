@@ -0,0 +1,40 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestINIHandler(t *testing.T) {
+	handler, urls, err := INIHandlerWithUrls([]byte(`# comments and blank lines are ignored
+
+/a = https://example.com/a
+  /spaced   =   https://example.com/spaced
+/query = https://example.com/search?q=a=b
+`), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 3 {
+		t.Errorf("parsed %d urls, want 3", len(urls))
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "trimmed", target: "/spaced", status: http.StatusMovedPermanently, location: "https://example.com/spaced"},
+		{name: "first separator", target: "/query", status: http.StatusMovedPermanently, location: "https://example.com/search?q=a=b"},
+		{name: "comment", target: "/#", status: http.StatusNotFound},
+		{name: "miss", target: "/b", status: http.StatusNotFound},
+	})
+}
+
+func TestINIHandlerErrors(t *testing.T) {
+	_, err := INIHandler([]byte("/a = https://example.com/a\n\n/b https://example.com/b\n"), http.NotFoundHandler())
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("INIHandler(missing separator) = %v, want an error naming line 3", err)
+	}
+	_, err = INIHandler([]byte("/a = https://example.com/1\n/a = https://example.com/2\n"), http.NotFoundHandler())
+	if err == nil {
+		t.Error("INIHandler(duplicate) = nil error")
+	}
+}
@@ -0,0 +1,91 @@
+package urlshort
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// gzipMagic starts every gzip stream. No text config format can
+// start with it, so it safely tells compressed input apart.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// maxDecompressedSize caps how much gzipped input may expand
+// to, so that a small compressed config cannot exhaust memory.
+const maxDecompressedSize = 64 << 20
+
+// utf8BOM is the byte order mark some Windows editors write at
+// the start of UTF-8 files.
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// prepareInput returns config input ready for parsing,
+// decompressing it first if it is gzipped, and dropping any
+// UTF-8 byte order mark at its start.
+func prepareInput(input []byte) ([]byte, error) {
+	if !bytes.HasPrefix(input, gzipMagic) {
+		return bytes.TrimPrefix(input, utf8BOM), nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(input))
+	if err != nil {
+		return nil, gunzipError(err)
+	}
+	defer reader.Close()
+	decompressed, err := io.ReadAll(limitDecompressed(reader))
+	if err != nil {
+		return nil, gunzipError(err)
+	}
+	return bytes.TrimPrefix(decompressed, utf8BOM), nil
+}
+
+// prepareReader works like prepareInput, but for a stream.
+func prepareReader(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(len(gzipMagic))
+	if err != nil || !bytes.Equal(magic, gzipMagic) {
+		// Leave read errors for the decoder to report.
+		return skipBOM(buffered), nil
+	}
+	reader, err := gzip.NewReader(buffered)
+	if err != nil {
+		return nil, gunzipError(err)
+	}
+	return skipBOM(bufio.NewReader(limitDecompressed(reader))), nil
+}
+
+// limitDecompressed returns a reader reading r that fails with
+// ErrInputTooLarge once more than maxDecompressedSize bytes
+// have been read from it.
+func limitDecompressed(r io.Reader) io.Reader {
+	return &sizeLimitedReader{r: io.LimitReader(r, maxDecompressedSize+1)}
+}
+
+type sizeLimitedReader struct {
+	r    io.Reader
+	read int64
+}
+
+func (lr *sizeLimitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	lr.read += int64(n)
+	if lr.read > maxDecompressedSize {
+		return 0, fmt.Errorf("%w: more than %d bytes once decompressed", ErrInputTooLarge, maxDecompressedSize)
+	}
+	return n, err
+}
+
+// skipBOM discards a UTF-8 byte order mark at the start of r.
+func skipBOM(r *bufio.Reader) *bufio.Reader {
+	if start, err := r.Peek(len(utf8BOM)); err == nil && bytes.Equal(start, utf8BOM) {
+		r.Discard(len(utf8BOM))
+	}
+	return r
+}
+
+func gunzipError(err error) error {
+	err = fmt.Errorf("decompressing gzip input: %w", err)
+	slog.Error("Error: " + err.Error())
+	return err
+}
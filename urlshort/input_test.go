@@ -0,0 +1,89 @@
+package urlshort
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPrepareInputGzip(t *testing.T) {
+	config := []byte("- path: /a\n  url: https://example.com\n")
+	got, err := prepareInput(gzipped(t, append([]byte("\xef\xbb\xbf"), config...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, config) {
+		t.Errorf("got %q, want %q", got, config)
+	}
+}
+
+func TestPrepareInputGzipTooLarge(t *testing.T) {
+	bomb := gzipped(t, make([]byte, maxDecompressedSize+1))
+	if _, err := prepareInput(bomb); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("prepareInput: got %v, want ErrInputTooLarge", err)
+	}
+
+	reader, err := prepareReader(bytes.NewReader(bomb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(io.Discard, reader); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("prepareReader: got %v, want ErrInputTooLarge", err)
+	}
+}
+
+func TestPrepareInputGzipAtLimit(t *testing.T) {
+	got, err := prepareInput(gzipped(t, make([]byte, maxDecompressedSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != maxDecompressedSize {
+		t.Errorf("got %d bytes, want %d", len(got), maxDecompressedSize)
+	}
+}
+
+func TestHandlersAcceptBOM(t *testing.T) {
+	bom := "\xef\xbb\xbf"
+	tests := []struct {
+		name  string
+		build func() (http.Handler, error)
+	}{
+		{"YAML", func() (http.Handler, error) {
+			return YAMLHandler([]byte(bom+"- path: /a\n  url: https://example.com/a\n"), http.NotFoundHandler())
+		}},
+		{"JSON", func() (http.Handler, error) {
+			return JSONHandler([]byte(bom+`[{"path": "/a", "url": "https://example.com/a"}]`), http.NotFoundHandler())
+		}},
+		{"gzipped YAML", func() (http.Handler, error) {
+			return YAMLHandler(gzipped(t, []byte(bom+"- path: /a\n  url: https://example.com/a\n")), http.NotFoundHandler())
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, err := tt.build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRedirects(t, handler, []redirectCase{
+				{target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+				{target: "/b", status: http.StatusNotFound},
+			})
+		})
+	}
+}
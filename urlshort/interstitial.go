@@ -0,0 +1,67 @@
+package urlshort
+
+import (
+	"bytes"
+	"html/template"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultInterstitialDelay is how long the interstitial page is
+// shown when Options.InterstitialDelay is zero.
+const defaultInterstitialDelay = 2 * time.Second
+
+// Interstitial is the data an interstitial page is rendered
+// with.
+type Interstitial struct {
+	// Path is the requested path, once normalized.
+	Path string
+	// URL is where the page leads to.
+	URL string
+	// Seconds is how long the page is shown before moving on.
+	Seconds int
+}
+
+var interstitialTemplate = template.Must(template.New("interstitial").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Seconds}}; url={{.URL}}">
+<title>Redirecting</title>
+</head>
+<body>
+<p>Redirecting to <a href="{{.URL}}">{{.URL}}</a> in {{.Seconds}} seconds.</p>
+</body>
+</html>
+`))
+
+// interstitial writes the interstitial page leading to url,
+// along with the headers the options ask for and then headers.
+// The page is left out for HEAD.
+func (rd *redirector) interstitial(w http.ResponseWriter, r *http.Request, path, url string, headers map[string]string) {
+	tmpl := rd.opts.InterstitialTemplate
+	if tmpl == nil {
+		tmpl = interstitialTemplate
+	}
+	var page bytes.Buffer
+	err := tmpl.Execute(&page, Interstitial{
+		Path:    path,
+		URL:     url,
+		Seconds: int(math.Ceil(rd.opts.InterstitialDelay.Seconds())),
+	})
+	if err != nil {
+		rd.log(r, slog.LevelError, "Error while rendering interstitial", slog.Any("error", err))
+		rd.error(w, r, http.StatusInternalServerError)
+		return
+	}
+	rd.setHeaders(w, headers)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(page.Len()))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(page.Bytes())
+	}
+}
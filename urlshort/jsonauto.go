@@ -0,0 +1,38 @@
+package urlshort
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// JSONHandlerAuto works like JSONHandler when the JSON is an
+// array of entries, and like JSONMapHandler when it is an object
+// keyed by path, telling them apart by their first character, so
+// callers do not need to know which form a producer uses:
+//
+//	[{"path": "/some-path", "url": "https://www.some-url.com/demo"}]
+//	{"/some-path": "https://www.some-url.com/demo"}
+//
+// An error is returned if the JSON is neither an array nor an
+// object, or for the same reasons as the handler it picks.
+func JSONHandlerAuto(jsonInput []byte, fallback http.Handler) (http.Handler, error) {
+	jsonInput, err := prepareInput(jsonInput)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimLeft(jsonInput, " \t\r\n")
+	switch {
+	case len(trimmed) == 0:
+		err = fmt.Errorf("json: empty input, expected an array or an object")
+	case trimmed[0] == '[':
+		return JSONHandler(jsonInput, fallback)
+	case trimmed[0] == '{':
+		return JSONMapHandler(jsonInput, fallback)
+	default:
+		err = fmt.Errorf("json: expected an array or an object, found '%c'", trimmed[0])
+	}
+	slog.Error("Error: " + err.Error())
+	return nil, err
+}
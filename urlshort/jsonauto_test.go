@@ -0,0 +1,49 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestJSONHandlerAuto(t *testing.T) {
+	for _, tc := range []struct {
+		name, input string
+	}{
+		{name: "array", input: `[{"path": "/a", "url": "https://example.com/a"}]`},
+		{name: "object", input: `{"/a": "https://example.com/a"}`},
+		{name: "leading whitespace", input: "\n\t  {\"/a\": \"https://example.com/a\"}"},
+		{name: "byte order mark", input: "\xef\xbb\xbf[{\"path\": \"/a\", \"url\": \"https://example.com/a\"}]"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, err := JSONHandlerAuto([]byte(tc.input), http.NotFoundHandler())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRedirects(t, handler, []redirectCase{
+				{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+				{name: "miss", target: "/b", status: http.StatusNotFound},
+			})
+		})
+	}
+}
+
+func TestJSONHandlerAutoErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, input string
+	}{
+		{name: "empty", input: "  \n"},
+		{name: "string", input: `"/a"`},
+		{name: "truncated array", input: `[{"path": "/a"`},
+		{name: "truncated object", input: `{"/a": `},
+		{name: "duplicate", input: `[
+			{"path": "/a", "url": "https://example.com/1"},
+			{"path": "/a", "url": "https://example.com/2"}
+		]`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := JSONHandlerAuto([]byte(tc.input), http.NotFoundHandler()); err == nil {
+				t.Error("JSONHandlerAuto() = nil error")
+			}
+		})
+	}
+}
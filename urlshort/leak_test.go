@@ -0,0 +1,78 @@
+package urlshort
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// checkNoLeak fails the test if, once the test has cleaned up,
+// more goroutines are running than when it started. Goroutines
+// take a moment to exit, so it gives them a second to do so.
+func checkNoLeak(t *testing.T) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		http.DefaultClient.CloseIdleConnections()
+		deadline := time.Now().Add(time.Second)
+		for {
+			after := runtime.NumGoroutine()
+			if after <= before {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Errorf("%d goroutines left running after Close", after-before)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+func TestFileHandlerCloseStopsWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.yaml")
+	if err := os.WriteFile(path, []byte("- path: /a\n  url: https://example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	checkNoLeak(t)
+
+	fh, err := NewFileHandler(path, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fh.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fh.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestRemoteConfigHandlerCloseStopsPoller(t *testing.T) {
+	checkNoLeak(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"path": "/a", "url": "https://example.com"}]`))
+	}))
+	t.Cleanup(server.Close)
+
+	rh, err := NewRemoteConfigHandler(context.Background(), server.URL, "json", 10*time.Millisecond, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Let a few refetches happen before closing.
+	time.Sleep(50 * time.Millisecond)
+	if err := rh.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rh.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if stats := rh.ReloadStats(); stats.Successes < 2 {
+		t.Errorf("got %d successful fetches, want at least 2", stats.Successes)
+	}
+}
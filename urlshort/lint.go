@@ -0,0 +1,145 @@
+package urlshort
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Severity is how serious a LintFinding is.
+type Severity string
+
+const (
+	// SeverityError marks a finding that would make the entry
+	// fail validation or not redirect where it should.
+	SeverityError Severity = "error"
+	// SeverityWarning marks a finding that is likely a mistake,
+	// but does not stop the entry from working.
+	SeverityWarning Severity = "warning"
+)
+
+// LintFinding is a single problem found by LintConfig.
+type LintFinding struct {
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path"`
+	Message  string   `json:"message"`
+}
+
+// LintConfig checks urls for likely mistakes and returns what
+// it finds, in the order of the entries, or nil if it finds
+// nothing. It is meant for tools such as pre-commit hooks, and
+// unlike validation never stops a config from loading.
+//
+// Errors are reported for empty paths, paths listed twice with
+// different urls, counting aliases and paths that are the same
+// once decoded, and urls that are not absolute. Warnings are
+// reported for paths listed twice with the same url, entries
+// expiring before their NotBefore, scheme-relative urls, urls
+// using a scheme other than http or https, and urls whose host
+// looks unreachable from the outside, such as localhost,
+// private addresses or reserved names like example.com.
+func LintConfig(urls ShortenedUrls) []LintFinding {
+	var findings []LintFinding
+	report := func(severity Severity, path, format string, args ...any) {
+		findings = append(findings, LintFinding{
+			Severity: severity,
+			Path:     path,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Paths are keyed as the handlers key them, so that aliases
+	// and paths that only differ once decoded count as repeats.
+	opts, _ := Options{}.withDefaults()
+	seen := make(map[string]ShortenedUrl, len(urls))
+	for i, entry := range urls {
+		paths := entryPaths(entry)
+		if len(paths) == 0 || strings.TrimSpace(paths[0]) == "" {
+			report(SeverityError, entry.Path, "entry %d has an empty path", i)
+			continue
+		}
+		repeated := true
+		for _, path := range paths {
+			key := opts.entryKey(path)
+			existing, exists := seen[key]
+			if !exists {
+				existing = entry
+				existing.Path = path
+				seen[key] = existing
+				repeated = false
+				continue
+			}
+			if sameDestination(existing, entry) {
+				if existing.Path == path {
+					report(SeverityWarning, path, "path is listed more than once")
+				} else {
+					report(SeverityWarning, path, "path is the same as '%s'", existing.Path)
+				}
+				continue
+			}
+			repeated = false
+			if existing.Path == path {
+				report(SeverityError, path, "path points to both '%s' and '%s'", existing.Url, entry.Url)
+			} else {
+				report(SeverityError, path, "path is the same as '%s', which points to '%s' instead of '%s'", existing.Path, existing.Url, entry.Url)
+			}
+		}
+		if repeated {
+			continue
+		}
+		if entry.NotBefore != nil && entry.ExpiresAt != nil && !entry.NotBefore.Before(*entry.ExpiresAt) {
+			report(SeverityWarning, entry.Path, "entry expires before it becomes active")
+		}
+		if entry.Gone {
+			continue
+		}
+		for _, rawUrl := range entryUrls(entry) {
+			parsed, err := url.Parse(rawUrl)
+			if err != nil || parsed.Host == "" {
+				report(SeverityError, entry.Path, "url '%s' is not absolute", rawUrl)
+				continue
+			}
+			switch parsed.Scheme {
+			case "http", "https":
+			case "":
+				report(SeverityWarning, entry.Path, "url '%s' is scheme-relative, so clients keep the scheme of the short url", rawUrl)
+			default:
+				report(SeverityWarning, entry.Path, "url '%s' uses scheme '%s'", rawUrl, parsed.Scheme)
+			}
+			if reason := unreachableHost(parsed.Hostname()); reason != "" {
+				report(SeverityWarning, entry.Path, "url '%s' %s", rawUrl, reason)
+			}
+		}
+	}
+	return findings
+}
+
+// unreachableHost returns why host looks unreachable for
+// visitors of the redirects, or "" if it looks fine.
+func unreachableHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+			return "points to a local or private address"
+		}
+		return ""
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return "points to localhost"
+	}
+	for _, reserved := range []string{"example", "test", "invalid", "local"} {
+		if strings.HasSuffix(host, "."+reserved) || host == reserved {
+			return "points to the reserved domain ." + reserved
+		}
+	}
+	for _, example := range []string{"example.com", "example.net", "example.org"} {
+		if host == example || strings.HasSuffix(host, "."+example) {
+			return "points to the example domain " + example
+		}
+	}
+	if !strings.Contains(host, ".") {
+		return "has a host without a domain"
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package urlshort
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintConfigRepeatedPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		urls ShortenedUrls
+		want []LintFinding
+	}{
+		{
+			name: "same url",
+			urls: ShortenedUrls{
+				{Path: "/a", Url: "https://www.gophercises.com"},
+				{Path: "/a", Url: "https://www.gophercises.com"},
+			},
+			want: []LintFinding{{SeverityWarning, "/a", "path is listed more than once"}},
+		},
+		{
+			name: "alias conflict",
+			urls: ShortenedUrls{
+				{Path: "/a", Url: "https://www.gophercises.com"},
+				{Path: "/b", Paths: []string{"/a"}, Url: "https://go.dev"},
+			},
+			want: []LintFinding{{SeverityError, "/a", "path points to both 'https://www.gophercises.com' and 'https://go.dev'"}},
+		},
+		{
+			name: "decoded conflict",
+			urls: ShortenedUrls{
+				{Path: "/caf%C3%A9", Url: "https://www.gophercises.com"},
+				{Path: "/café", Url: "https://go.dev"},
+			},
+			want: []LintFinding{{SeverityError, "/café", "path is the same as '/caf%C3%A9', which points to 'https://www.gophercises.com' instead of 'https://go.dev'"}},
+		},
+		{
+			name: "aliases only",
+			urls: ShortenedUrls{
+				{Paths: []string{"/a", "/b"}, Url: "https://go.dev"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LintConfig(tt.urls); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LintConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ListingHandler returns an http.HandlerFunc that responds with
+// all the mappings as a JSON array of ShortenedUrl, sorted by
+// path, in the same format JSONHandler reads. It is meant to be
+// mounted on an admin path, such as /admin/links.
+//
+// The map is copied, so later changes to it are not listed.
+func ListingHandler(pathsToUrls map[string]string) http.HandlerFunc {
+	return UrlsListingHandler(urlsFromMap(pathsToUrls))
+}
+
+// listedUrl is an entry as listed by UrlsListingHandler.
+type listedUrl struct {
+	ShortenedUrl
+	State ScheduleState `json:"state"`
+}
+
+// UrlsListingHandler works like ListingHandler, but lists the
+// given entries, so that fields such as Description are
+// included. The entries are sorted by path without changing
+// urls. Each entry also gets a state field, the ScheduleState
+// it is in at the time of the request, which JSONHandler
+// ignores.
+func UrlsListingHandler(urls ShortenedUrls) http.HandlerFunc {
+	return UrlsListingHandlerWithOptions(urls, Options{})
+}
+
+// UrlsListingHandlerWithOptions works like UrlsListingHandler,
+// but tells the time of the request with opts.Now, so that the
+// states listed agree with those of a handler built with the
+// same opts, such as one given a fixed clock in tests. The
+// other options are ignored.
+func UrlsListingHandlerWithOptions(urls ShortenedUrls, opts Options) http.HandlerFunc {
+	sorted := sortedByPath(urls)
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := opts.now()
+		listed := make([]listedUrl, len(sorted))
+		for i, entry := range sorted {
+			listed[i] = listedUrl{ShortenedUrl: entry, State: entry.StateAt(now)}
+		}
+		body, err := json.Marshal(listed)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+// sortedByPath returns a copy of urls sorted by path, keeping
+// entries with the same path in their order.
+func sortedByPath(urls ShortenedUrls) ShortenedUrls {
+	sorted := append(ShortenedUrls{}, urls...)
+	slices.SortStableFunc(sorted, func(a, b ShortenedUrl) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return sorted
+}
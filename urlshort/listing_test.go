@@ -0,0 +1,64 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScheduledEntries(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	urls := ShortenedUrls{
+		{Path: "/active", Url: "https://example.com/active", NotBefore: &past, ExpiresAt: &future},
+		{Path: "/expired", Url: "https://example.com/expired", ExpiresAt: &past},
+		{Path: "/scheduled", Url: "https://example.com/scheduled", NotBefore: &future},
+	}
+	opts := Options{Now: func() time.Time { return now }, Logger: discardLogger}
+	handler, err := UrlsHandler(urls, opts, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+		state    ScheduleState
+	}{
+		{"/active", http.StatusMovedPermanently, "https://example.com/active", ScheduleActive},
+		{"/expired", http.StatusNotFound, "", ScheduleExpired},
+		{"/scheduled", http.StatusNotFound, "", ScheduleScheduled},
+	}
+
+	rec := httptest.NewRecorder()
+	UrlsListingHandlerWithOptions(urls, opts).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/links", nil))
+	var listed []struct {
+		Path  string        `json:"path"`
+		State ScheduleState `json:"state"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != len(tests) {
+		t.Fatalf("listed %d entries, want %d", len(listed), len(tests))
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+			if listed[i].Path != tt.path || listed[i].State != tt.state {
+				t.Errorf("listed %s as %s, want %s as %s", listed[i].Path, listed[i].State, tt.path, tt.state)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// PathLooker is implemented by handlers that can tell what they
+// would answer a request for a path with, without a request or
+// a response. It is meant for admin tools and tests. Every
+// handler in this package that implements Matcher implements it
+// too.
+//
+// The method is named LookupPath rather than Lookup on purpose:
+// several handlers also implement Store, whose Lookup returns
+// the raw url an entry maps to, before any matching, and a
+// handler cannot have two methods by that name. LookupPath
+// instead reports the full answer, status included.
+type PathLooker interface {
+	// LookupPath returns the url and status a GET request for
+	// path would be answered with, and true, or false if the
+	// request would be passed to the fallback. The path goes
+	// through the same normalization and matching as in
+	// ServeHTTP, and may include a query. For an entry that is
+	// gone, the url is empty and the status is 410. For an
+	// interstitial page the status is 200, and for a proxied
+	// entry it is 0, since it comes from the proxied url.
+	LookupPath(path string) (url string, status int, matched bool)
+}
+
+// RequestLooker is implemented by handlers that can tell what
+// they would answer a request with, without serving it. Unlike
+// PathLooker, it sees the whole request, so entries that depend
+// on its host or headers, such as those of HostHandler or the
+// MatchHeaders rules of QueryHandler, match as when served.
+// Every handler in this package that implements PathLooker
+// implements it too, and ResolveHandler uses it when it can.
+type RequestLooker interface {
+	// LookupRequest returns the url and status r would be
+	// answered with, and true, or false if r would be passed to
+	// the fallback, as described on PathLooker.LookupPath.
+	LookupRequest(r *http.Request) (url string, status int, matched bool)
+}
+
+// lookupRequest returns a GET request for path, which may
+// include a query, or false if path is not a valid request
+// target.
+func lookupRequest(path string) (*http.Request, bool) {
+	target, err := url.ParseRequestURI(path)
+	if err != nil {
+		return nil, false
+	}
+	return &http.Request{
+		Method:     http.MethodGet,
+		URL:        target,
+		Header:     http.Header{},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+	}, true
+}
+
+// lookupPath implements PathLooker for looker, with a GET
+// request for path.
+func lookupPath(looker RequestLooker, path string) (string, int, bool) {
+	r, ok := lookupRequest(path)
+	if !ok {
+		return "", 0, false
+	}
+	return looker.LookupRequest(r)
+}
+
+// LookupPath implements PathLooker.
+func (pr *pathRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(pr, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (pr *pathRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	return pr.matchStatus(r, pr.lookup)
+}
+
+// LookupPath implements PathLooker.
+func (pr *patternRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(pr, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (pr *patternRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	return pr.matchStatus(r, pr.lookup)
+}
+
+// LookupPath implements PathLooker.
+func (rr *regexRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(rr, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (rr *regexRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	return rr.matchStatus(r, rr.lookup)
+}
+
+// LookupPath implements PathLooker.
+func (sr *sliceRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(sr, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (sr *sliceRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	return sr.matchStatus(r, sr.lookup)
+}
+
+// LookupPath implements PathLooker.
+func (sr *starlarkRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(sr, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (sr *starlarkRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	return sr.matchStatus(r, sr.lookup)
+}
+
+// LookupPath implements PathLooker.
+func (fh *FileHandler) LookupPath(path string) (string, int, bool) {
+	return lookupPath(fh, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (fh *FileHandler) LookupRequest(r *http.Request) (string, int, bool) {
+	return fh.matchStatus(r, fh.lookup)
+}
+
+// LookupPath implements PathLooker.
+func (rh *RemoteConfigHandler) LookupPath(path string) (string, int, bool) {
+	return lookupPath(rh, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (rh *RemoteConfigHandler) LookupRequest(r *http.Request) (string, int, bool) {
+	return rh.matchStatus(r, rh.lookup)
+}
+
+// LookupPath implements PathLooker.
+func (sh *SQLHandler) LookupPath(path string) (string, int, bool) {
+	return lookupPath(sh, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (sh *SQLHandler) LookupRequest(r *http.Request) (string, int, bool) {
+	return sh.matchStatus(r, sh.lookup)
+}
+
+// LookupPath implements PathLooker. While paused, every path is
+// reported as a miss.
+func (mh *MutableHandler) LookupPath(path string) (string, int, bool) {
+	return lookupPath(mh, path)
+}
+
+// LookupRequest implements RequestLooker. While paused, every request
+// is reported as a miss.
+func (mh *MutableHandler) LookupRequest(r *http.Request) (string, int, bool) {
+	if mh.Paused() {
+		return "", 0, false
+	}
+	return mh.matchStatus(r, mh.lookup)
+}
+
+// LookupPath implements PathLooker.
+func (ah *AtomicMapHandler) LookupPath(path string) (string, int, bool) {
+	return lookupPath(ah, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (ah *AtomicMapHandler) LookupRequest(r *http.Request) (string, int, bool) {
+	return ah.matchStatus(r, ah.lookup)
+}
+
+// LookupPath implements PathLooker. While paused, every path is
+// reported as a miss.
+func (rr *reloadableRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(rr, path)
+}
+
+// LookupRequest implements RequestLooker. While paused, every request
+// is reported as a miss.
+func (rr *reloadableRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	if rr.Paused() {
+		return "", 0, false
+	}
+	return rr.matchStatus(r, rr.lookup)
+}
+
+// LookupPath implements PathLooker, for a request without a
+// Host header, which only the entries for every host match.
+func (hr *hostRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(hr, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (hr *hostRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	return hr.matchStatus(r, hr.lookup(r.Host))
+}
+
+// LookupPath implements PathLooker, matching the query of path
+// against the query rules.
+func (qr *queryRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(qr, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (qr *queryRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	return qr.matchStatus(r, qr.lookup(r))
+}
+
+// LookupPath implements PathLooker. The func is called with the
+// request for path.
+func (fr *funcRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(fr, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (fr *funcRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	return fr.matchStatus(r, fr.lookup(r))
+}
+
+// LookupPath implements PathLooker.
+func (dr *dbRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(dr, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (dr *dbRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	return dr.matchStatus(r, dr.lookupIn(r.Context(), dr, "database"))
+}
+
+// LookupPath implements PathLooker.
+func (rr *redisRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(rr, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (rr *redisRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	return rr.matchStatus(r, rr.lookupIn(r.Context(), rr, "Redis"))
+}
+
+// LookupPath implements PathLooker.
+func (br *boltRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(br, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (br *boltRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	return br.matchStatus(r, br.lookupIn(r.Context(), br, "bbolt"))
+}
+
+// LookupPath implements PathLooker.
+func (sr *storeRedirector) LookupPath(path string) (string, int, bool) {
+	return lookupPath(sr, path)
+}
+
+// LookupRequest implements RequestLooker.
+func (sr *storeRedirector) LookupRequest(r *http.Request) (string, int, bool) {
+	return sr.matchStatus(r, sr.lookupIn(r.Context(), sr.store, "store"))
+}
+
+// LookupPath implements PathLooker.
+func (lh *LastAccessHandler) LookupPath(path string) (string, int, bool) {
+	return lh.handler.LookupPath(path)
+}
+
+// LookupRequest implements RequestLooker.
+func (lh *LastAccessHandler) LookupRequest(r *http.Request) (string, int, bool) {
+	return lh.handler.LookupRequest(r)
+}
+
+// LookupPath implements PathLooker.
+func (ch *CountingHandler) LookupPath(path string) (string, int, bool) {
+	return ch.handler.LookupPath(path)
+}
+
+// LookupRequest implements RequestLooker.
+func (ch *CountingHandler) LookupRequest(r *http.Request) (string, int, bool) {
+	return ch.handler.LookupRequest(r)
+}
+
+// LookupPath implements PathLooker, reporting what the first
+// handler matching path would answer. Handlers that do not
+// implement PathLooker are skipped, so a path only they would
+// match is reported as a miss.
+func (ch *chainHandler) LookupPath(path string) (string, int, bool) {
+	for _, handler := range ch.handlers {
+		looker, ok := handler.(PathLooker)
+		if !ok {
+			continue
+		}
+		if url, status, matched := looker.LookupPath(path); matched {
+			return url, status, true
+		}
+	}
+	return "", 0, false
+}
+
+// LookupRequest implements RequestLooker, as LookupPath does.
+// Handlers that only implement PathLooker are asked about the
+// path and query of r.
+func (ch *chainHandler) LookupRequest(r *http.Request) (string, int, bool) {
+	for _, handler := range ch.handlers {
+		if url, status, matched, ok := lookupAny(handler, r); ok && matched {
+			return url, status, true
+		}
+	}
+	return "", 0, false
+}
+
+// lookupAny looks r up with handler, through RequestLooker if
+// it implements it, or else PathLooker. The last result is
+// false if handler implements neither.
+func lookupAny(handler any, r *http.Request) (string, int, bool, bool) {
+	switch looker := handler.(type) {
+	case RequestLooker:
+		url, status, matched := looker.LookupRequest(r)
+		return url, status, matched, true
+	case PathLooker:
+		url, status, matched := looker.LookupPath(r.URL.RequestURI())
+		return url, status, matched, true
+	}
+	return "", 0, false, false
+}
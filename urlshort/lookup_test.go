@@ -0,0 +1,29 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestChainLookupPathSkipsNonLookers(t *testing.T) {
+	plain := http.NotFoundHandler()
+	mapped := MapHandler(map[string]string{"/a": "https://a.example.com"}, nil)
+	chain := ChainHandler(plain, mapped).(PathLooker)
+
+	tests := []struct {
+		path    string
+		url     string
+		status  int
+		matched bool
+	}{
+		{"/a", "https://a.example.com", http.StatusMovedPermanently, true},
+		{"/b", "", 0, false},
+	}
+	for _, tt := range tests {
+		url, status, matched := chain.LookupPath(tt.path)
+		if url != tt.url || status != tt.status || matched != tt.matched {
+			t.Errorf("LookupPath(%q) = %q, %d, %t, want %q, %d, %t",
+				tt.path, url, status, matched, tt.url, tt.status, tt.matched)
+		}
+	}
+}
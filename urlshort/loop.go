@@ -0,0 +1,180 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// redirectsToItself reports whether redirecting r to rawUrl
+// would send the client back to the url of r, so that it would
+// loop forever. Relative urls are resolved against r, and a
+// change of scheme, such as from http to https, is not a loop.
+func redirectsToItself(r *http.Request, rawUrl string) bool {
+	destination, err := url.Parse(rawUrl)
+	if err != nil {
+		return false
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	current := &url.URL{Scheme: scheme, Host: r.Host, Path: r.URL.Path, RawQuery: r.URL.RawQuery}
+	target := current.ResolveReference(destination)
+	if target.Path == "" {
+		target.Path = "/"
+	}
+	if current.Path == "" {
+		current.Path = "/"
+	}
+	return target.Scheme == current.Scheme &&
+		strings.EqualFold(target.Host, current.Host) &&
+		target.Path == current.Path &&
+		target.RawQuery == current.RawQuery
+}
+
+// pointsToItself reports whether entry has a relative url
+// that is the same as one of its own paths. Absolute urls can
+// only be told to point back to the server once a request
+// shows its host, which serve then checks.
+func pointsToItself(entry ShortenedUrl, rawUrl string) bool {
+	destination, err := url.Parse(rawUrl)
+	if err != nil || destination.IsAbs() || destination.Host != "" {
+		return false
+	}
+	return slices.Contains(entryPaths(entry), destination.Path)
+}
+
+// maxChainDepth is the most hops Options.ResolveChains follows
+// from one entry before giving up.
+const maxChainDepth = 10
+
+// resolveChains points every entry whose url is the key of
+// another entry to where the chain of entries ends, as
+// described on Options.ResolveChains, recording a problem for
+// every chain that loops or is too long. indexes holds the
+// index in the config of the entry under each key.
+func (o Options) resolveChains(configErr *ConfigError, entries map[string]ShortenedUrl, indexes map[string]int) {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	resolved := make(map[string]ShortenedUrl, len(entries))
+	for _, key := range keys {
+		entry := entries[key]
+		next, ok := o.chainKey(entry, entries)
+		if !ok {
+			continue
+		}
+		visited := []string{key}
+		for {
+			if slices.Contains(visited, next) {
+				configErr.add(indexes[key], entry.Path, "redirect chain loops: %s",
+					strings.Join(append(visited, next), " -> "))
+				break
+			}
+			if len(visited) > maxChainDepth {
+				configErr.add(indexes[key], entry.Path, "redirect chain is longer than %d hops", maxChainDepth)
+				break
+			}
+			visited = append(visited, next)
+			following, ok := o.chainKey(entries[next], entries)
+			if !ok {
+				resolved[key] = entries[next]
+				break
+			}
+			next = following
+		}
+	}
+	// The last entry may be gone or pick between targets, rather
+	// than have a url, so take whichever it does.
+	for key, end := range resolved {
+		entry := entries[key]
+		entry.Url = end.Url
+		entry.Targets = end.Targets
+		entry.Gone = end.Gone
+		entries[key] = entry
+	}
+}
+
+// chainKey returns the key of the entry the url of entry names,
+// and true, if a chain goes on from entry.
+func (o Options) chainKey(entry ShortenedUrl, entries map[string]ShortenedUrl) (string, bool) {
+	if entry.Gone || len(entry.Targets) > 0 || strings.HasSuffix(entry.Path, "*") {
+		return "", false
+	}
+	destination, err := url.Parse(entry.Url)
+	if err != nil || destination.Scheme != "" || destination.Host != "" ||
+		destination.RawQuery != "" || destination.Fragment != "" || !strings.HasPrefix(destination.Path, "/") {
+		return "", false
+	}
+	key := o.entryKey(destination.Path)
+	next, exists := entries[key]
+	if !exists || strings.HasSuffix(next.Path, "*") {
+		return "", false
+	}
+	return key, true
+}
+
+// checkDangling records a problem for every entry with a url,
+// or target url, that is an absolute path no entry matches,
+// once resolved as the options ask. Run before resolveChains,
+// it reports a chain that leads nowhere once, on its last
+// entry. Other relative urls, such as b or ?q=1, depend on the
+// request and are not checked.
+func (o Options) checkDangling(configErr *ConfigError, entries map[string]ShortenedUrl, indexes map[string]int) {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	reported := map[int]bool{}
+	for _, key := range keys {
+		entry := entries[key]
+		if entry.Gone || reported[indexes[key]] {
+			continue
+		}
+		for _, rawUrl := range entryUrls(entry) {
+			destination, err := url.Parse(o.resolve(rawUrl))
+			if err != nil || destination.Scheme != "" || destination.Host != "" ||
+				!strings.HasPrefix(destination.Path, "/") || o.internalPathExists(destination.Path, entries) {
+				continue
+			}
+			configErr.add(indexes[key], entry.Path, "relative url '%s' matches no entry", rawUrl)
+			reported[indexes[key]] = true
+			break
+		}
+	}
+}
+
+// internalPathExists reports whether a request for path would
+// match one of entries, trying keys as redirector.match does,
+// or RootURL.
+func (o Options) internalPathExists(path string, entries map[string]ShortenedUrl) bool {
+	key := o.entryKey(path)
+	if o.RootURL != "" && key == "/" {
+		return true
+	}
+	if _, exists := entries[key]; exists {
+		return true
+	}
+	if alternate, ok := o.alternatePath(key); ok {
+		if _, exists := entries[alternate]; exists {
+			return true
+		}
+	}
+	if !o.PrefixMatching {
+		return false
+	}
+	for i := strings.LastIndex(key, "/"); i >= 0; i = strings.LastIndex(key[:i], "/") {
+		if _, exists := entries[key[:i+1]+"*"]; exists {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSelfRedirectIsAMiss(t *testing.T) {
+	// httptest.NewRequest sends requests to example.com over http.
+	urls := ShortenedUrls{
+		{Path: "/self", Url: "http://example.com/self"},
+		{Path: "/relative", Url: "/relative"},
+		{Path: "/upgrade", Url: "https://example.com/upgrade"},
+		{Path: "/query", Url: "http://example.com/query?v=2"},
+	}
+	handler, err := UrlsHandler(urls, Options{Logger: discardLogger}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "absolute", target: "/self", status: http.StatusNotFound},
+		{name: "relative", target: "/relative", status: http.StatusNotFound},
+		{name: "scheme change", target: "/upgrade", status: http.StatusMovedPermanently, location: "https://example.com/upgrade"},
+		{name: "query change", target: "/query", status: http.StatusMovedPermanently, location: "http://example.com/query?v=2"},
+	})
+}
+
+func TestValidateURLsRejectsSelfRedirect(t *testing.T) {
+	urls := ShortenedUrls{{Path: "/x", Url: "/x"}}
+	_, err := UrlsHandler(urls, Options{ValidateURLs: true}, nil)
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Problems[0].Path != "/x" {
+		t.Fatalf("got %v, want a ConfigError for /x", err)
+	}
+}
+
+func TestResolveChains(t *testing.T) {
+	tests := []struct {
+		name  string
+		urls  ShortenedUrls
+		cases []redirectCase
+	}{
+		{"plain", ShortenedUrls{
+			{Path: "/a", Url: "/b"},
+			{Path: "/b", Url: "/c"},
+			{Path: "/c", Url: "https://example.com/c"},
+		}, []redirectCase{
+			{name: "first", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/c"},
+			{name: "middle", target: "/b", status: http.StatusMovedPermanently, location: "https://example.com/c"},
+		}},
+		{"gone", ShortenedUrls{
+			{Path: "/a", Url: "/b"},
+			{Path: "/b", Gone: true},
+		}, []redirectCase{
+			{name: "first", target: "/a", status: http.StatusGone},
+			{name: "last", target: "/b", status: http.StatusGone},
+		}},
+		{"targets", ShortenedUrls{
+			{Path: "/c", Url: "/d"},
+			{Path: "/d", Targets: []Target{{Url: "https://example.com/d", Weight: 1}}},
+		}, []redirectCase{
+			{name: "first", target: "/c", status: http.StatusMovedPermanently, location: "https://example.com/d"},
+			{name: "last", target: "/d", status: http.StatusMovedPermanently, location: "https://example.com/d"},
+		}},
+		{"status kept", ShortenedUrls{
+			{Path: "/a", Url: "/b", Status: http.StatusFound},
+			{Path: "/b", Url: "https://example.com/b"},
+		}, []redirectCase{
+			{name: "first", target: "/a", status: http.StatusFound, location: "https://example.com/b"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, err := UrlsHandler(tt.urls, Options{ResolveChains: true, Logger: discardLogger}, http.NotFoundHandler())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRedirects(t, handler, tt.cases)
+		})
+	}
+}
+
+func TestResolveChainsErrors(t *testing.T) {
+	long := ShortenedUrls{}
+	for i := 0; i <= maxChainDepth+1; i++ {
+		long = append(long, ShortenedUrl{Path: fmt.Sprintf("/%02d", i), Url: fmt.Sprintf("/%02d", i+1)})
+	}
+	long = append(long, ShortenedUrl{Path: fmt.Sprintf("/%02d", maxChainDepth+2), Url: "https://example.com"})
+
+	tests := []struct {
+		name string
+		urls ShortenedUrls
+		want string
+	}{
+		{"loop", ShortenedUrls{{Path: "/a", Url: "/b"}, {Path: "/b", Url: "/a"}}, "loops: /a -> /b -> /a"},
+		{"self", ShortenedUrls{{Path: "/a", Url: "/a"}}, "loops: /a -> /a"},
+		{"too long", long, "longer than 10 hops"},
+		{"dangling", ShortenedUrls{{Path: "/a", Url: "/missing"}}, "matches no entry"},
+		{"dangling target", ShortenedUrls{
+			{Path: "/a", Targets: []Target{{Url: "/b", Weight: 1}, {Url: "/missing", Weight: 1}}},
+			{Path: "/b", Url: "https://example.com"},
+		}, "relative url '/missing' matches no entry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UrlsHandler(tt.urls, Options{ResolveChains: true}, nil)
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("got %v, want a ConfigError", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %q, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package urlshort
+
+import (
+	"log/slog"
+	"sort"
+)
+
+// MergeMaps combines several maps of paths to urls into a new
+// one. When a path is in more than one map, the url from the
+// last map wins, so later maps override earlier ones.
+func MergeMaps(maps ...map[string]string) map[string]string {
+	size := 0
+	for _, m := range maps {
+		size += len(m)
+	}
+	merged := make(map[string]string, size)
+	for _, m := range maps {
+		for path, url := range m {
+			merged[path] = url
+		}
+	}
+	return merged
+}
+
+// MergeUrls combines several ShortenedUrls into one, where each
+// path appears once. When a path appears more than once, the
+// last entry wins, whether the entries come from different
+// sources or from the same one, so duplicates are never an
+// error here.
+//
+// Paths keep the position at which they first appeared. Paths
+// are compared as the handlers do with default options, so
+// that aliases in Paths count, and an entry replaces the first
+// one sharing any of its paths.
+func MergeUrls(sources ...ShortenedUrls) ShortenedUrls {
+	opts, _ := Options{}.withDefaults()
+	var merged ShortenedUrls
+	positions := map[string]int{}
+	for _, urls := range sources {
+		for _, url := range urls {
+			paths := entryPaths(url)
+			position := len(merged)
+			for _, path := range paths {
+				if i, exists := positions[opts.entryKey(path)]; exists {
+					position = min(position, i)
+				}
+			}
+			if position == len(merged) {
+				merged = append(merged, url)
+			} else {
+				merged[position] = url
+			}
+			for _, path := range paths {
+				positions[opts.entryKey(path)] = position
+			}
+		}
+	}
+	return merged
+}
+
+// MergeMode selects what merging does when a path is mapped to
+// different urls by two sources.
+type MergeMode int
+
+const (
+	// MergeError makes a conflict an error listing every path
+	// mapped to different urls.
+	MergeError MergeMode = iota
+
+	// MergeWarn lets the last source win, and logs a warning
+	// naming the path and both urls for each override.
+	MergeWarn
+
+	// MergeSilent lets the last source win without a word.
+	MergeSilent
+)
+
+// MergeMapsMode works like MergeMaps, but handles paths mapped
+// to different urls by two maps according to mode. With
+// MergeError, the error is a *ConfigError with one problem per
+// conflict, whose Index is that of the map overriding the path.
+// Paths mapped to the same url twice are never a conflict.
+func MergeMapsMode(mode MergeMode, maps ...map[string]string) (map[string]string, error) {
+	merged := map[string]string{}
+	configErr := &ConfigError{}
+	for i, m := range maps {
+		for _, path := range sortedKeys(m) {
+			url := m[path]
+			if existing, exists := merged[path]; exists && existing != url {
+				switch mode {
+				case MergeError:
+					configErr.add(i, path, "'%s' overrides '%s'", url, existing)
+					continue
+				case MergeWarn:
+					slog.Warn("Overriding path while merging",
+						slog.String("path", path),
+						slog.String("url", url),
+						slog.String("previous", existing),
+						slog.Int("map", i))
+				}
+			}
+			merged[path] = url
+		}
+	}
+	if err := configErr.err(); err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+	return merged, nil
+}
+
+// sortedKeys returns the keys of m in order, so that problems
+// and warnings come out the same way every time.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
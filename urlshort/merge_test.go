@@ -0,0 +1,94 @@
+package urlshort
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMergeUrlsAliases(t *testing.T) {
+	merged := MergeUrls(
+		ShortenedUrls{
+			{Path: "/a", Url: "https://example.com/a"},
+			{Paths: []string{"/gh", "/github"}, Url: "https://github.com"},
+		},
+		ShortenedUrls{
+			{Path: "/github", Url: "https://github.com/new"},
+			{Path: "/b", Paths: []string{"/a"}, Url: "https://example.com/b"},
+		},
+	)
+	want := ShortenedUrls{
+		{Path: "/b", Paths: []string{"/a"}, Url: "https://example.com/b"},
+		{Path: "/github", Url: "https://github.com/new"},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("MergeUrls() = %+v, want %+v", merged, want)
+	}
+}
+
+func TestMergeMaps(t *testing.T) {
+	base := map[string]string{"/a": "https://example.com/a", "/b": "https://example.com/b"}
+	override := map[string]string{"/b": "https://example.com/new", "/c": "https://example.com/c"}
+	merged := MergeMaps(base, nil, override)
+	want := map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "https://example.com/new",
+		"/c": "https://example.com/c",
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("MergeMaps() = %v, want %v", merged, want)
+	}
+	if base["/b"] != "https://example.com/b" {
+		t.Error("MergeMaps() modified its input")
+	}
+	if merged := MergeMaps(); len(merged) != 0 {
+		t.Errorf("MergeMaps() = %v, want an empty map", merged)
+	}
+}
+
+func TestMergeMapsMode(t *testing.T) {
+	maps := []map[string]string{
+		{"/a": "https://example.com/a", "/b": "https://example.com/b"},
+		{"/a": "https://example.com/a", "/b": "https://example.com/new"},
+	}
+	_, err := MergeMapsMode(MergeError, maps...)
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || len(configErr.Problems) != 1 {
+		t.Fatalf("MergeMapsMode(MergeError) = %v, want one conflict", err)
+	}
+	if problem := configErr.Problems[0]; problem.Index != 1 || problem.Path != "/b" {
+		t.Errorf("problem = %+v, want /b in map 1", problem)
+	}
+	for _, mode := range []MergeMode{MergeWarn, MergeSilent} {
+		merged, err := MergeMapsMode(mode, maps...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if merged["/b"] != "https://example.com/new" {
+			t.Errorf("MergeMapsMode(%d)[/b] = %q, want the last url", mode, merged["/b"])
+		}
+	}
+}
+
+func TestMergeUrls(t *testing.T) {
+	merged := MergeUrls(
+		ShortenedUrls{
+			{Path: "/a", Url: "https://example.com/a"},
+			{Path: "/b", Url: "https://example.com/b"},
+			{Path: "/a", Url: "https://example.com/a2"},
+		},
+		nil,
+		ShortenedUrls{
+			{Path: "/c", Url: "https://example.com/c"},
+			{Path: "/b", Url: "https://example.com/b2", Status: 302},
+		},
+	)
+	want := ShortenedUrls{
+		{Path: "/a", Url: "https://example.com/a2"},
+		{Path: "/b", Url: "https://example.com/b2", Status: 302},
+		{Path: "/c", Url: "https://example.com/c"},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("MergeUrls() = %+v, want %+v", merged, want)
+	}
+}
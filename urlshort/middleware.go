@@ -0,0 +1,28 @@
+package urlshort
+
+import "net/http"
+
+// Middleware wraps an http.Handler in another one, for example
+// to add authentication, logging or panic recovery around the
+// handlers of this package, which all implement http.Handler.
+type Middleware func(http.Handler) http.Handler
+
+// Use returns h wrapped in every middleware in mw. They apply in
+// the order given: the first one sees each request first and
+// the response last. For example, with
+//
+//	handler := urlshort.Use(redirects, recoverer, logger)
+//
+// a request goes through recoverer, then logger, then redirects.
+// Handlers taking the next handler as their first argument fit
+// in with a closure:
+//
+//	handler := urlshort.Use(redirects, func(next http.Handler) http.Handler {
+//		return urlshort.HTTPSHandler(next, true)
+//	})
+func Use(h http.Handler, mw ...Middleware) http.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
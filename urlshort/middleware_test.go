@@ -0,0 +1,43 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUse(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	redirects := MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	handler := Use(redirects, trace("first"), auth, trace("last"))
+
+	checkRedirects(t, handler, []redirectCase{
+		{name: "rejected", target: "/a", status: http.StatusUnauthorized},
+		{name: "redirect", target: "/a", header: http.Header{"Authorization": {"token"}}, status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "miss", target: "/b", header: http.Header{"Authorization": {"token"}}, status: http.StatusNotFound},
+	})
+	if got, want := strings.Join(order, " "), "first first last first last"; got != want {
+		t.Errorf("middleware ran as %q, want %q", got, want)
+	}
+
+	if Use(redirects) != redirects {
+		t.Error("Use without middleware did not return the handler itself")
+	}
+}
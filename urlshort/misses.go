@@ -0,0 +1,63 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+)
+
+// maxRecordedMisses bounds the number of distinct paths a
+// MissRecorder keeps, so that requests for random paths cannot
+// grow it without limit.
+const maxRecordedMisses = 10000
+
+// MissRecorder is an http.Handler meant to be used as the
+// fallback of another handler. It counts the requests for each
+// path it receives, which are those no mapping matched, and
+// then passes them on to its own fallback. The counts show
+// which missing paths are popular enough to get a short link.
+// It is safe for concurrent use.
+//
+// Only the first 10000 distinct paths are recorded; requests
+// for other paths are still passed on, but not counted.
+type MissRecorder struct {
+	fallback http.Handler
+	mu       sync.Mutex
+	misses   map[string]int64
+}
+
+// NewMissRecorder returns a MissRecorder that passes every
+// request on to fallback after counting it:
+//
+//	recorder := urlshort.NewMissRecorder(http.NotFoundHandler())
+//	handler := urlshort.MapHandler(pathsToUrls, recorder)
+func NewMissRecorder(fallback http.Handler) *MissRecorder {
+	return &MissRecorder{
+		fallback: fallback,
+		misses:   map[string]int64{},
+	}
+}
+
+func (mr *MissRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	mr.record(r.URL.Path)
+	mr.fallback.ServeHTTP(w, r)
+}
+
+// Misses returns a snapshot of the number of requests received
+// for each path.
+func (mr *MissRecorder) Misses() map[string]int64 {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	misses := make(map[string]int64, len(mr.misses))
+	for path, count := range mr.misses {
+		misses[path] = count
+	}
+	return misses
+}
+
+func (mr *MissRecorder) record(path string) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.misses[path]; exists || len(mr.misses) < maxRecordedMisses {
+		mr.misses[path]++
+	}
+}
@@ -0,0 +1,59 @@
+package urlshort
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgPackHandler will parse the provided MessagePack and then
+// return an http.Handler that will attempt to map any paths to
+// their corresponding URL. If the path is not provided in the
+// MessagePack, then the fallback http.Handler will be called
+// instead.
+//
+// MessagePack is expected to be an array of maps, with the same
+// keys as the objects read by JSONHandler. In JSON notation:
+//
+//	[{"path": "/some-path", "url": "https://www.some-url.com/demo"}]
+//
+// The only errors that can be returned all related to having
+// invalid MessagePack data or a path that points to two
+// different urls.
+func MsgPackHandler(msgpackInput []byte, fallback http.Handler) (http.Handler, error) {
+	handler, _, err := MsgPackHandlerWithUrls(msgpackInput, fallback)
+	return handler, err
+}
+
+// MsgPackHandlerWithUrls works like MsgPackHandler, but also
+// returns the ShortenedUrls parsed from the MessagePack.
+func MsgPackHandlerWithUrls(msgpackInput []byte, fallback http.Handler) (http.Handler, ShortenedUrls, error) {
+	parsedMsgPack, err := parseMsgPack(msgpackInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := UrlsHandler(parsedMsgPack, Options{}, fallback)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, parsedMsgPack, nil
+}
+
+func parseMsgPack(msgpackInput []byte) (ShortenedUrls, error) {
+	msgpackInput, err := prepareInput(msgpackInput)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls ShortenedUrls
+	err = decodeSafely("msgpack", func() error {
+		return msgpack.Unmarshal(msgpackInput, &urls)
+	})
+	if err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	return urls, nil
+}
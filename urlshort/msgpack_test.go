@@ -0,0 +1,65 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgPackHandler(t *testing.T) {
+	input, err := msgpack.Marshal([]map[string]any{
+		{"path": "/a", "url": "https://example.com/a"},
+		{"path": "/old", "paths": []string{"/older"}, "url": "https://example.com/old", "status": 302},
+		{"path": "/gone", "gone": true},
+		{"path": "/once", "url": "https://example.com/once", "max_hits": 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, urls, err := MsgPackHandlerWithUrls(input, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 4 {
+		t.Errorf("parsed %d urls, want 4", len(urls))
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "status", target: "/old", status: http.StatusFound, location: "https://example.com/old"},
+		{name: "alias", target: "/older", status: http.StatusFound, location: "https://example.com/old"},
+		{name: "gone", target: "/gone", status: http.StatusGone},
+		{name: "max hits", target: "/once", status: http.StatusMovedPermanently, location: "https://example.com/once"},
+		{name: "max hits used up", target: "/once", status: http.StatusGone},
+		{name: "miss", target: "/b", status: http.StatusNotFound},
+	})
+}
+
+func TestMsgPackHandlerErrors(t *testing.T) {
+	wrongType, err := msgpack.Marshal([]map[string]any{{"path": "/a", "status": "moved"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicate, err := msgpack.Marshal([]map[string]any{
+		{"path": "/a", "url": "https://example.com/1"},
+		{"path": "/a", "url": "https://example.com/2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name  string
+		input []byte
+	}{
+		{name: "truncated", input: duplicate[:len(duplicate)-4]},
+		{name: "not an array", input: []byte{0xa3, 'a', 'b', 'c'}},
+		{name: "wrong type", input: wrongType},
+		{name: "duplicate", input: duplicate},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := MsgPackHandler(tc.input, http.NotFoundHandler()); err == nil {
+				t.Error("MsgPackHandler() = nil error")
+			}
+		})
+	}
+}
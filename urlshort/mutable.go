@@ -0,0 +1,121 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+)
+
+// MutableHandler is an http.Handler that behaves like the one
+// returned by MapHandler, but whose mappings can be changed
+// while it is serving requests. It can also be paused, for
+// example during maintenance. It is safe for concurrent use.
+type MutableHandler struct {
+	redirector
+	pauser
+	mu      sync.RWMutex
+	entries map[string]ShortenedUrl
+}
+
+// NewMutableHandler returns a MutableHandler that starts out
+// with the mappings in initial, which may be nil. The map is
+// copied, so later changes to it do not affect the handler.
+// If a path is not mapped, then the fallback http.Handler will
+// be called instead.
+func NewMutableHandler(initial map[string]string, fallback http.Handler) *MutableHandler {
+	opts, _ := Options{}.withDefaults()
+	return &MutableHandler{
+		redirector: redirector{opts: opts, fallback: fallback},
+		entries:    opts.mapEntries(initial),
+	}
+}
+
+// Set maps path to url, replacing any url the path pointed to.
+func (mh *MutableHandler) Set(path, url string) {
+	mh.mu.Lock()
+	defer mh.mu.Unlock()
+	mh.entries[mh.opts.entryKey(path)] = ShortenedUrl{Path: path, Url: url}
+}
+
+// ImportAll adds every enabled entry in urls, replacing the
+// mappings of paths already mapped, or adds none of them. The
+// entries are checked first, as the options of the handler ask
+// for, and with every url required to be absolute: if any path
+// is empty, any url is not an absolute http or https url, or
+// any path points to two different urls, the *ConfigError
+// listing every problem is returned and the handler is left
+// unchanged. Disabled entries are left out, so they never
+// conflict with enabled ones. Requests being served never see
+// part of an import.
+func (mh *MutableHandler) ImportAll(urls ShortenedUrls) error {
+	opts := mh.opts
+	opts.ValidateURLs = true
+	entries, err := opts.buildEntries(urls)
+	if err != nil {
+		return err
+	}
+	mh.mu.Lock()
+	defer mh.mu.Unlock()
+	for key, entry := range entries {
+		mh.entries[key] = entry
+	}
+	return nil
+}
+
+// Snapshot returns a copy of every mapping, one entry per path
+// sorted by it, taken all at once while no change is under way.
+// Pass it to Restore, here or on another handler, or to
+// ExportUrlsYAML or ExportUrlsJSON to save it.
+func (mh *MutableHandler) Snapshot() ShortenedUrls {
+	mh.mu.RLock()
+	defer mh.mu.RUnlock()
+	return snapshotEntries(mh.entries)
+}
+
+// Restore replaces every mapping with those in urls, such as a
+// Snapshot, or changes nothing. If any path points to two
+// different urls, or any entry has invalid targets or status,
+// the *ConfigError listing every problem is returned and the
+// handler is left unchanged. Unlike ImportAll, urls are not
+// checked to be absolute, so that any snapshot can be restored.
+// Requests being served see either the old mappings or the new
+// ones, never a mix.
+func (mh *MutableHandler) Restore(urls ShortenedUrls) error {
+	entries, err := mh.opts.buildEntries(urls)
+	if err != nil {
+		return err
+	}
+	mh.mu.Lock()
+	defer mh.mu.Unlock()
+	mh.entries = entries
+	return nil
+}
+
+// Delete removes the mapping for path, if there is one.
+func (mh *MutableHandler) Delete(path string) {
+	mh.mu.Lock()
+	defer mh.mu.Unlock()
+	delete(mh.entries, mh.opts.entryKey(path))
+}
+
+func (mh *MutableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if mh.servePaused(w, r, mh.fallback) {
+		return
+	}
+	mh.serve(w, r, mh.lookup)
+}
+
+// Match implements Matcher. While paused, every request is
+// reported as a miss.
+func (mh *MutableHandler) Match(r *http.Request) (string, bool) {
+	if mh.Paused() {
+		return "", false
+	}
+	return mh.matchRequest(r, mh.lookup)
+}
+
+func (mh *MutableHandler) lookup(key string) (ShortenedUrl, bool) {
+	mh.mu.RLock()
+	defer mh.mu.RUnlock()
+	entry, exists := mh.entries[key]
+	return entry, exists
+}
@@ -0,0 +1,43 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestImportAll(t *testing.T) {
+	handler := NewMutableHandler(nil, http.NotFoundHandler())
+	disabled := false
+	err := handler.ImportAll(ShortenedUrls{
+		{Path: "/a", Url: "https://example.com/old", Enabled: &disabled},
+		{Path: "/a", Url: "https://example.com/a"},
+		{Path: "/old", Gone: true},
+		{Paths: []string{"/gh"}, Url: "https://github.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "enabled wins", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "gone", target: "/old", status: http.StatusGone},
+		{name: "alias only", target: "/gh", status: http.StatusMovedPermanently, location: "https://github.com"},
+	})
+}
+
+func TestImportAllRejected(t *testing.T) {
+	handler := NewMutableHandler(nil, http.NotFoundHandler())
+	handler.Set("/keep", "https://example.com/keep")
+	err := handler.ImportAll(ShortenedUrls{
+		{Path: "/a", Url: "https://example.com/a"},
+		{Path: "/b", Url: "example.com/b"},
+	})
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("ImportAll() = %v, want a *ConfigError", err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "unchanged", target: "/keep", status: http.StatusMovedPermanently, location: "https://example.com/keep"},
+		{name: "not imported", target: "/a", status: http.StatusNotFound},
+	})
+}
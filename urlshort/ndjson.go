@@ -0,0 +1,86 @@
+package urlshort
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// NDJSONHandler will decode newline-delimited JSON from r and
+// then return an http.Handler that will attempt to map any
+// paths to their corresponding URL. If the path is not provided
+// in the input, then the fallback http.Handler will be called
+// instead.
+//
+// The input is expected to have one JSON object per line, with
+// the same fields JSONHandler reads. Blank lines are skipped:
+//
+//	{"path": "/some-path", "url": "https://www.some-url.com/demo"}
+//	{"path": "/other-path", "url": "https://www.other-url.com"}
+//
+// The input is decoded line by line as it is read, so it never
+// has to be held in memory whole. The only errors that can be
+// returned are read errors from r, lines that are not a single
+// JSON object, which name the offending line number, or a path
+// that points to two different urls.
+func NDJSONHandler(r io.Reader, fallback http.Handler) (http.Handler, error) {
+	parsedNdjson, err := decodeNDJSON(r)
+	if err != nil {
+		return nil, err
+	}
+	return UrlsHandler(parsedNdjson, Options{}, fallback)
+}
+
+func parseNDJSON(ndjsonInput []byte) (ShortenedUrls, error) {
+	return decodeNDJSON(bytes.NewReader(ndjsonInput))
+}
+
+func decodeNDJSON(r io.Reader) (ShortenedUrls, error) {
+	r, err := prepareReader(r)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(r)
+
+	var urls ShortenedUrls
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			slog.Error("Error: " + err.Error())
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			url, decodeErr := decodeNDJSONLine(trimmed)
+			if decodeErr != nil {
+				decodeErr = fmt.Errorf("ndjson line %d: %w", lineNumber, decodeErr)
+				slog.Error("Error: " + decodeErr.Error())
+				return nil, decodeErr
+			}
+			urls = append(urls, url)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	return urls, nil
+}
+
+// decodeNDJSONLine decodes line, which must hold exactly one
+// JSON object.
+func decodeNDJSONLine(line []byte) (ShortenedUrl, error) {
+	var url ShortenedUrl
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	if err := decoder.Decode(&url); err != nil {
+		return ShortenedUrl{}, err
+	}
+	if decoder.More() {
+		return ShortenedUrl{}, errors.New("unexpected data after object")
+	}
+	return url, nil
+}
@@ -0,0 +1,50 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNDJSONHandler(t *testing.T) {
+	handler, err := NDJSONHandler(strings.NewReader(`{"path": "/a", "url": "https://example.com/a"}
+
+  {"path": "/old", "paths": ["/older"], "url": "https://example.com/old", "status": 302}
+{"path": "/gone", "gone": true}`), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "status", target: "/old", status: http.StatusFound, location: "https://example.com/old"},
+		{name: "alias", target: "/older", status: http.StatusFound, location: "https://example.com/old"},
+		{name: "last line without newline", target: "/gone", status: http.StatusGone},
+		{name: "miss", target: "/b", status: http.StatusNotFound},
+	})
+}
+
+func TestNDJSONHandlerErrors(t *testing.T) {
+	_, err := NDJSONHandler(strings.NewReader("{\"path\": \"/a\", \"url\": \"https://example.com/a\"}\n{\"path\": \"/b\"\n"), http.NotFoundHandler())
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("NDJSONHandler(truncated object) = %v, want an error naming line 2", err)
+	}
+	for _, tc := range []struct {
+		name, input string
+	}{
+		{name: "two objects on a line", input: `{"path": "/a", "url": "https://example.com/a"} {"path": "/b", "url": "https://example.com/b"}`},
+		{name: "array", input: `[{"path": "/a", "url": "https://example.com/a"}]`},
+		{name: "duplicate", input: "{\"path\": \"/a\", \"url\": \"https://example.com/1\"}\n{\"path\": \"/a\", \"url\": \"https://example.com/2\"}\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NDJSONHandler(strings.NewReader(tc.input), http.NotFoundHandler()); err == nil {
+				t.Error("NDJSONHandler() = nil error")
+			}
+		})
+	}
+	readErr := errors.New("connection reset")
+	if _, err := NDJSONHandler(iotest.ErrReader(readErr), http.NotFoundHandler()); !errors.Is(err, readErr) {
+		t.Errorf("NDJSONHandler(failing reader) = %v, want %v", err, readErr)
+	}
+}
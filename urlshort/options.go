@@ -0,0 +1,731 @@
+package urlshort
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// TrailingSlash selects how a trailing slash at the end of a
+// path affects matching.
+type TrailingSlash int
+
+const (
+	// TrailingSlashExact matches paths as they are, so /docs and
+	// /docs/ are different paths.
+	TrailingSlashExact TrailingSlash = iota
+
+	// TrailingSlashStrip removes the trailing slash from both the
+	// paths in the map and the request path, so /docs/ matches a
+	// /docs key and the other way round.
+	TrailingSlashStrip
+
+	// TrailingSlashAdd adds a trailing slash to both the paths in
+	// the map and the request path. It matches the same requests
+	// as TrailingSlashStrip.
+	TrailingSlashAdd
+
+	// TrailingSlashEither looks up the request path as it is
+	// first, then with its trailing slash added or removed. The
+	// paths in the map are kept as they are, so /docs and /docs/
+	// can still point to different urls.
+	TrailingSlashEither
+)
+
+// Options configures the behavior of the handlers returned by
+// MapHandlerWithOptions.
+type Options struct {
+	// Status is the HTTP status code used for redirects. It must
+	// be one of 301, 302, 307 or 308. Zero means 301.
+	//
+	// With 301 and 302, clients may, and most do, follow the
+	// redirect with a GET, dropping the body of a POST or the
+	// method of a PUT. With 307 and 308, they must repeat the
+	// request with the same method and body, which API clients
+	// rely on. 301 and 308 are permanent and cached by browsers,
+	// while 302 and 307 are not.
+	Status int
+
+	// CaseInsensitive makes path lookups ignore case, so that
+	// /GitHub and /github resolve to the same url. Paths in the
+	// map which only differ in case must point to the same url.
+	CaseInsensitive bool
+
+	// AccentInsensitive makes path lookups ignore diacritics, so
+	// that /cafe and /café resolve to the same url. Both the
+	// paths in the map and the request path are decomposed to
+	// Unicode NFKD, stripped of combining marks and recomposed to
+	// NFC. Case is kept, unless CaseInsensitive is set too. NFKD
+	// also folds compatibility characters, such as the ligature
+	// "ﬁ" into "fi" and full-width letters into plain ones. Paths
+	// in the map which only differ in accents must point to the
+	// same url.
+	AccentInsensitive bool
+
+	// StripQuery makes path lookups ignore everything from the
+	// first '?' or '#' of the decoded path, before any other
+	// normalization. The query string of a request is never part
+	// of its path, so /x?y=1 matches /x either way; this is for
+	// paths that still hold one, such as /x%3Fy=1 from a link
+	// whose query got percent-encoded, or /x#frag from a client
+	// sending the fragment it should have dropped, which then
+	// match /x too. Paths in the map are cut the same way. Only
+	// the real query string is looked at by the query conditions
+	// of QueryHandler, which does not take Options: a query cut
+	// from the path never selects an entry with Query.
+	StripQuery bool
+
+	// StripSuffixes lists suffixes cut from paths before they are
+	// matched, for legacy links such as /page/index.html or
+	// /page.php to match /page. Suffixes are cut as written, so
+	// list /index.html rather than index.html to also drop the
+	// slash, and only the first one listed that the path ends
+	// with is cut. A path that would be left empty becomes /.
+	// Paths in the map are cut the same way. Suffixes are folded
+	// like paths under CaseInsensitive and AccentInsensitive. Set
+	// CanonicalRedirect as well to redirect such requests to the
+	// stripped path instead of serving them as they are.
+	StripSuffixes []string
+
+	// TrailingSlash selects how trailing slashes are matched.
+	// The zero value, TrailingSlashExact, matches paths exactly.
+	TrailingSlash TrailingSlash
+
+	// PrefixMatching enables keys ending in "/*", which match any
+	// path under them. See PrefixHandler for the details.
+	PrefixMatching bool
+
+	// ForwardQuery appends the query string of the incoming
+	// request to the url being redirected to. If that url has
+	// a query of its own, both are kept, joined with "&".
+	ForwardQuery bool
+
+	// ExpandEnv expands ${VAR} and $VAR references in urls when
+	// the handler is built, using LookupEnv. Paths are never
+	// expanded.
+	ExpandEnv bool
+
+	// LookupEnv looks up the variables for ExpandEnv. When nil,
+	// os.LookupEnv is used.
+	LookupEnv func(name string) (string, bool)
+
+	// StrictEnv makes ExpandEnv fail when a url references an
+	// unset variable, instead of expanding it to an empty string.
+	StrictEnv bool
+
+	// ValidateURLs rejects mappings whose url is not an absolute
+	// http or https url, as well as those with an empty or blank
+	// path or url, or a relative url equal to their own path,
+	// which would loop. The error is a *ConfigError listing
+	// every invalid entry by its index and path.
+	ValidateURLs bool
+
+	// ResolveChains collapses chains of entries when the handler
+	// is built: an entry whose url is a relative path mapped by
+	// another entry, such as /a pointing to /b while /b points to
+	// https://example.com/c, redirects straight to where the last
+	// entry of the chain points. Only urls naming exactly the key
+	// of another plain entry, without a query or fragment, are
+	// followed; entries with Targets, Gone or a prefix key end a
+	// chain, and the first entry then picks between the same
+	// targets, or is gone too. A chain longer than 10 hops, or
+	// one that leads back to an entry already in it, is an error.
+	// So is a url, or target url, that is still an absolute path
+	// once chains are resolved, such as /b with no entry for /b,
+	// since it would dangle; paths under a prefix key are fine,
+	// as are relative urls made absolute by BaseURL or
+	// DefaultScheme.
+	ResolveChains bool
+
+	// AllowedHosts, when set, lists the only hosts urls may point
+	// to, guarding against a config that redirects to arbitrary
+	// sites, an open redirect. "*.example.com" allows every
+	// subdomain of example.com, but not example.com itself. Hosts
+	// are compared without case or port, and relative urls, which
+	// stay on this site, are always allowed. An entry with a url,
+	// or any of its Targets, on another host fails the build with
+	// a *ConfigError, unless SkipDisallowedHosts is set. DefaultURL
+	// and RootURL, which are set in code, are not checked. Every
+	// host is allowed by default.
+	AllowedHosts []string
+
+	// SkipDisallowedHosts makes entries pointing to a host outside
+	// AllowedHosts be left out with a warning, instead of failing
+	// the build.
+	SkipDisallowedHosts bool
+
+	// CacheControl, when set, is sent as the Cache-Control header
+	// of every redirect, for example "no-store" or "max-age=3600",
+	// to stop browsers from caching permanent redirects forever.
+	CacheControl string
+
+	// Methods lists the HTTP methods that are redirected, for the
+	// entries which do not list their own. Requests using other
+	// methods get a 405 with an Allow header. When empty, every
+	// method is redirected. OPTIONS requests for a mapped path
+	// are never redirected, and get a 204 with an Allow header
+	// listing these methods instead, unless the path is proxied.
+	Methods []string
+
+	// BaseURL, when set, is the absolute url that destinations
+	// without a scheme, such as /new-docs, are resolved against
+	// before redirecting. Absolute destinations are left as they
+	// are.
+	BaseURL string
+
+	// DefaultScheme, when set to http or https, is the scheme
+	// given to scheme-relative destinations, such as
+	// //cdn.example.com/x, before redirecting or validating them.
+	// Otherwise, such destinations take the scheme of BaseURL if
+	// there is one, or are sent as they are in the Location
+	// header. Browsers then keep the scheme of the short url,
+	// but other clients may not follow them at all, and
+	// ValidateURLs rejects them.
+	DefaultScheme string
+
+	// DefaultURL, when set, is where requests for unmapped paths
+	// are redirected, using Status, instead of being passed to
+	// the fallback handler.
+	DefaultURL string
+
+	// RootURL, when set, is where requests for the root path "/"
+	// are redirected. It takes precedence over any "/" entry in
+	// the map, so the homepage can be set without touching the
+	// rest of the config. A request with an empty path, as some
+	// proxies send, is not a request for "/": it goes to the
+	// fallback.
+	RootURL string
+
+	// Now returns the current time, used to decide whether an
+	// entry has expired. When nil, time.Now is used.
+	Now func() time.Time
+
+	// Random returns a number in [0, 1), used to pick between the
+	// Targets of an entry. When nil, math/rand is used. A handler
+	// never calls it concurrently, so tests can pass the Float64
+	// method of a seeded *rand.Rand, as long as no other handler
+	// shares it.
+	Random func() float64
+
+	// OnRedirect, when set, is called each time a request is
+	// redirected, or proxied, to an entry, with the key the entry
+	// was found under and the url the request is sent to.
+	OnRedirect func(r *http.Request, key, url string)
+
+	// Rewrite, when set, is called with each request matching an
+	// entry and the url it is about to be sent to, after
+	// ForwardQuery and BaseURL are applied, and returns the url to
+	// send it to instead, for example with tracking parameters
+	// added or another host picked from the request context. A
+	// result that cannot be parsed, or that is not an absolute
+	// http or https url while the original was, is logged and the
+	// original url used. It is also called by Match and
+	// LookupPath, so it should have no side effects. DefaultURL
+	// and RootURL are not rewritten.
+	Rewrite func(r *http.Request, url string) string
+
+	// OnMiss, when set, is called each time no entry matches a
+	// request, before it is redirected to DefaultURL or passed to
+	// the fallback handler.
+	OnMiss func(r *http.Request)
+
+	// OnServed, when set, is called once each request is served,
+	// with the time it took from entering the handler until the
+	// response, or the call to the fallback handler, returned.
+	// For handlers looking paths up in a database, this is mostly
+	// the time of the lookup. Leaving it nil skips the timing.
+	OnServed func(r *http.Request, elapsed time.Duration)
+
+	// Logger receives a log line for each request served. When
+	// nil, or when it has no slog.Handler, such as a zero
+	// slog.Logger, slog.Default() is used, so leaving it unset is
+	// always safe.
+	Logger *slog.Logger
+
+	// RedirectLevel is the level of the log line written for a
+	// request that gets redirected. When nil, slog.LevelInfo is
+	// used.
+	RedirectLevel slog.Leveler
+
+	// MissLevel is the level of the log line written for a
+	// request passed to the fallback handler. Misses are expected
+	// in most setups, so when nil, slog.LevelDebug is used.
+	MissLevel slog.Leveler
+
+	// LogSampleRate, when above 1, makes the handler write only
+	// one in that many of its request log lines below
+	// slog.LevelWarn, such as those for redirects and misses, to
+	// keep busy links from flooding the logs. The lines are
+	// counted together, whatever their path, and the first one is
+	// always written. Warnings and errors are never left out.
+	// When 0 or 1, every line is written.
+	LogSampleRate int
+
+	// Proxy makes every matched request get the content of its
+	// url through a reverse proxy, instead of a redirect, as if
+	// every entry had Proxy set. The proxied request keeps the
+	// method, body and headers of the original, except for
+	// hop-by-hop headers such as Connection, goes to the host of
+	// the url with the path and query of the url, and gets
+	// X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto
+	// headers. Cookies and credentials are forwarded too, so only
+	// proxy to trusted hosts. The response is streamed back as it
+	// arrives, and the Headers of the entry are added to it. If
+	// the url cannot be reached, the client gets a 502 Bad
+	// Gateway. Status and CacheControl do not apply.
+	Proxy bool
+
+	// Interstitial makes every matched request get an HTML page
+	// which sends the browser on to the url after
+	// InterstitialDelay, with a link to follow it at once,
+	// instead of a redirect, as if every entry had Interstitial
+	// set. The page is served with a 200 and the Headers of the
+	// entry. Proxy takes precedence over it.
+	Interstitial bool
+
+	// InterstitialDelay is how long the interstitial page is
+	// shown, rounded up to whole seconds. When zero, it is two
+	// seconds.
+	InterstitialDelay time.Duration
+
+	// InterstitialTemplate, when set, renders the interstitial
+	// page in place of the built-in one, with an Interstitial as
+	// its data.
+	InterstitialTemplate *template.Template
+
+	// RedirectTemplate, when set, renders the body of redirects to
+	// GET requests in place of the short one of http.Redirect,
+	// for clients and bots that read it, with a RedirectPage as
+	// its data. The status and Location header are the same
+	// either way.
+	RedirectTemplate *template.Template
+
+	// CanonicalRedirect makes requests for a mapped path that is
+	// not in its normalized form, such as /Docs when matching is
+	// case insensitive, /café when it is accent insensitive,
+	// /docs when trailing slashes are added, or /page/index.html
+	// when StripSuffixes lists /index.html, first get a 301 to
+	// the normalized path, such as /docs/, on the same host. The
+	// next request then gets the redirect of the path, so clients
+	// take one more hop, but only ever see one form of each path.
+	// Requests that match nothing are not affected.
+	// TrailingSlashEither has no normalized form, so it only gets
+	// case fixes.
+	CanonicalRedirect bool
+
+	// MaxPathLength, when positive, is the longest request path,
+	// in bytes, that is looked up. Requests with longer paths get
+	// a 414 URI Too Long without any lookup, so that abusive
+	// requests cost next to nothing. When zero, paths of any
+	// length are looked up.
+	MaxPathLength int
+
+	// RejectControlChars makes requests whose path contains ASCII
+	// control characters, such as a newline or NUL, get a 400 Bad
+	// Request without any lookup.
+	RejectControlChars bool
+
+	// Debug makes every response say how the request was
+	// matched, for diagnosing a config in staging: a request
+	// matching an entry gets an X-Shortlink-Match header naming
+	// the key it matched, plus an X-Shortlink-Source header naming
+	// the config file of the entry when it was read from one, and
+	// a miss gets X-Shortlink-Miss: true. It exposes the config,
+	// so leave it off in production, as it is by default.
+	Debug bool
+
+	// RequestIDHeader, when set, names a request header, such as
+	// X-Request-ID, whose value is added to every request log
+	// line as a request_id attribute, so that redirects can be
+	// traced across services. Requests without the header are
+	// logged without the attribute.
+	RequestIDHeader string
+
+	// ContextAttrs, when set, is called with the context of each
+	// request logged, and the attributes it returns are added to
+	// every log line for that request, so that values stored by
+	// earlier middleware, such as a user ID, end up in the logs.
+	ContextAttrs func(ctx context.Context) []slog.Attr
+
+	// logCount counts the log lines sampled by LogSampleRate,
+	// shared by the copies of the options made once withDefaults
+	// set it.
+	logCount *atomic.Uint64
+
+	// base is BaseURL once parsed by withDefaults.
+	base *url.URL
+
+	// allowedHosts is AllowedHosts as a set of lowercase hosts,
+	// built by withDefaults.
+	allowedHosts map[string]bool
+}
+
+func (o Options) withDefaults() (Options, error) {
+	if o.Status == 0 {
+		o.Status = http.StatusMovedPermanently
+	}
+	if o.Random != nil {
+		o.Random = lockedRandom(o.Random)
+	}
+	if err := validateStatus(o.Status); err != nil {
+		return o, err
+	}
+	if o.InterstitialDelay < 0 {
+		return o, fmt.Errorf("invalid interstitial delay: %s", o.InterstitialDelay)
+	}
+	if o.InterstitialDelay == 0 {
+		o.InterstitialDelay = defaultInterstitialDelay
+	}
+	if o.LogSampleRate < 0 {
+		return o, fmt.Errorf("invalid log sample rate: %d", o.LogSampleRate)
+	}
+	if o.LogSampleRate > 1 && o.logCount == nil {
+		o.logCount = new(atomic.Uint64)
+	}
+	if o.MaxPathLength < 0 {
+		return o, fmt.Errorf("invalid max path length: %d", o.MaxPathLength)
+	}
+	if o.TrailingSlash < TrailingSlashExact || o.TrailingSlash > TrailingSlashEither {
+		return o, fmt.Errorf("invalid trailing slash mode: %d", o.TrailingSlash)
+	}
+	if o.DefaultScheme != "" && o.DefaultScheme != "http" && o.DefaultScheme != "https" {
+		return o, fmt.Errorf("invalid default scheme '%s': must be http or https", o.DefaultScheme)
+	}
+	if o.BaseURL != "" {
+		base, err := url.Parse(o.BaseURL)
+		if err != nil || !base.IsAbs() || base.Host == "" {
+			return o, fmt.Errorf("invalid base url '%s': must be an absolute url", o.BaseURL)
+		}
+		o.base = base
+	}
+	if len(o.AllowedHosts) > 0 {
+		o.allowedHosts = make(map[string]bool, len(o.AllowedHosts))
+		for _, host := range o.AllowedHosts {
+			if strings.TrimPrefix(host, "*.") == "" {
+				return o, fmt.Errorf("invalid allowed host '%s'", host)
+			}
+			o.allowedHosts[strings.ToLower(host)] = true
+		}
+	}
+	if o.ValidateURLs && o.DefaultURL != "" {
+		if err := validateUrl(o.resolve(o.DefaultURL)); err != nil {
+			return o, fmt.Errorf("default url: %w", err)
+		}
+	}
+	if o.ValidateURLs && o.RootURL != "" {
+		if err := validateUrl(o.resolve(o.RootURL)); err != nil {
+			return o, fmt.Errorf("root url: %w", err)
+		}
+	}
+	return o, nil
+}
+
+// logger returns the logger to use for request logs. Every
+// log line written on behalf of a handler must go through it,
+// rather than through o.Logger, which may be unusable.
+func (o Options) logger() *slog.Logger {
+	if o.Logger == nil || o.Logger.Handler() == nil {
+		return slog.Default()
+	}
+	return o.Logger
+}
+
+// sampleLog reports whether a request log line at level should
+// be written, as LogSampleRate asks.
+func (o Options) sampleLog(level slog.Level) bool {
+	if o.logCount == nil || level >= slog.LevelWarn {
+		return true
+	}
+	return (o.logCount.Add(1)-1)%uint64(o.LogSampleRate) == 0
+}
+
+func (o Options) redirectLevel() slog.Level {
+	if o.RedirectLevel == nil {
+		return slog.LevelInfo
+	}
+	return o.RedirectLevel.Level()
+}
+
+func (o Options) missLevel() slog.Level {
+	if o.MissLevel == nil {
+		return slog.LevelDebug
+	}
+	return o.MissLevel.Level()
+}
+
+// now returns the current time according to the options.
+func (o Options) now() time.Time {
+	if o.Now == nil {
+		return time.Now()
+	}
+	return o.Now()
+}
+
+// rejectPath returns the status to answer a request for path
+// with, without looking it up, or 0 if it should be looked up.
+func (o Options) rejectPath(path string) int {
+	if o.MaxPathLength > 0 && len(path) > o.MaxPathLength {
+		return http.StatusRequestURITooLong
+	}
+	if o.RejectControlChars && strings.ContainsFunc(path, func(r rune) bool {
+		return r < 0x20 || r == 0x7f
+	}) {
+		return http.StatusBadRequest
+	}
+	return 0
+}
+
+// entryKey turns the path of an entry, as written in a config,
+// into the form used as its key in the map of paths to urls.
+//
+// Keys are always decoded: a path written percent-encoded, such
+// as /caf%C3%A9, is stored as /café, the same form net/http
+// gives in r.URL.Path for a request for either spelling. This
+// means an encoded reserved character, such as %2F, matches the
+// character itself. A path that is not validly encoded, such as
+// /100%, is kept as it is.
+func (o Options) entryKey(path string) string {
+	return o.normalizePath(unescapePath(path))
+}
+
+// unescapePath decodes any percent-encoding in path, or returns
+// path unchanged if it is not validly encoded.
+func unescapePath(path string) string {
+	if !strings.Contains(path, "%") {
+		return path
+	}
+	unescaped, err := url.PathUnescape(path)
+	if err != nil {
+		return path
+	}
+	return unescaped
+}
+
+// normalizePath turns a decoded path into the form used as a
+// key in the map of paths to urls.
+func (o Options) normalizePath(path string) string {
+	if o.StripQuery {
+		if i := strings.IndexAny(path, "?#"); i >= 0 {
+			path = path[:i]
+		}
+	}
+	if o.AccentInsensitive {
+		path = foldAccents(path)
+	}
+	if o.CaseInsensitive {
+		path = strings.ToLower(path)
+	}
+	for _, suffix := range o.StripSuffixes {
+		if o.AccentInsensitive {
+			suffix = foldAccents(suffix)
+		}
+		if o.CaseInsensitive {
+			suffix = strings.ToLower(suffix)
+		}
+		if stripped, found := strings.CutSuffix(path, suffix); found && suffix != "" {
+			if stripped == "" {
+				stripped = "/"
+			}
+			path = stripped
+			break
+		}
+	}
+	switch o.TrailingSlash {
+	case TrailingSlashStrip:
+		if len(path) > 1 {
+			path = strings.TrimSuffix(path, "/")
+		}
+	case TrailingSlashAdd:
+		if !strings.HasSuffix(path, "/") && !strings.HasSuffix(path, "/*") {
+			path += "/"
+		}
+	}
+	return path
+}
+
+// alternatePath returns the other form of path to try when it
+// is not found, if the options ask for one.
+func (o Options) alternatePath(path string) (string, bool) {
+	if o.TrailingSlash != TrailingSlashEither || path == "/" || path == "" {
+		return "", false
+	}
+	if strings.HasSuffix(path, "/") {
+		return strings.TrimSuffix(path, "/"), true
+	}
+	return path + "/", true
+}
+
+// resolve returns rawUrl with the default scheme, if there is
+// one and rawUrl is scheme-relative, or else resolved against
+// the base url, if there is one and rawUrl has no scheme.
+func (o Options) resolve(rawUrl string) string {
+	if o.base == nil && o.DefaultScheme == "" {
+		return rawUrl
+	}
+	ref, err := url.Parse(rawUrl)
+	if err != nil || ref.Scheme != "" {
+		return rawUrl
+	}
+	if o.DefaultScheme != "" && ref.Host != "" {
+		ref.Scheme = o.DefaultScheme
+		return ref.String()
+	}
+	if o.base == nil {
+		return rawUrl
+	}
+	return o.base.ResolveReference(ref).String()
+}
+
+// destination returns the url that a request matching a path
+// mapped to url should be redirected to.
+func (o Options) destination(url string, r *http.Request) string {
+	url = o.resolve(url)
+	if o.ForwardQuery && r.URL.RawQuery != "" {
+		url = appendQuery(url, r.URL.RawQuery)
+	}
+	return url
+}
+
+// buildEntries turns urls into a map of entries keyed by their
+// normalized path, leaving out disabled entries so that they
+// never conflict with the others. It fails with a *ConfigError
+// if two entries end up under the same key but point to
+// different urls, or if the entries fail the validation the
+// options ask for.
+func (o Options) buildEntries(urls ShortenedUrls) (map[string]ShortenedUrl, error) {
+	if o.ExpandEnv {
+		expanded, err := o.expandUrls(urls)
+		if err != nil {
+			return nil, err
+		}
+		urls = expanded
+	}
+	if o.ValidateURLs {
+		if err := validateUrls(urls, o.resolve); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.allowedHosts != nil {
+		allowed, err := o.checkHosts(urls)
+		if err != nil {
+			return nil, err
+		}
+		urls = allowed
+	}
+
+	configErr := &ConfigError{}
+	checkTargets(configErr, urls)
+	checkStatuses(configErr, urls)
+	checkMaxHits(configErr, urls)
+	entries := make(map[string]ShortenedUrl, len(urls))
+	indexes := make(map[string]int, len(urls))
+	for i, entry := range urls {
+		if !entry.IsEnabled() {
+			continue
+		}
+		if entry.MaxHits > 0 {
+			entry.hits = new(atomic.Int64)
+		}
+		for _, path := range entryPaths(entry) {
+			entry := entry
+			entry.Path = path
+			key := o.entryKey(path)
+			if existing, exists := entries[key]; exists && !sameDestination(existing, entry) {
+				if existing.Path == entry.Path {
+					configErr.add(i, entry.Path, "duplicate path: '%s' and '%s'", existing.Url, entry.Url)
+				} else {
+					configErr.add(i, entry.Path, "conflicts with path '%s': '%s' != '%s'", existing.Path, entry.Url, existing.Url)
+				}
+				continue
+			}
+			entries[key] = entry
+			indexes[key] = i
+		}
+	}
+	if o.ResolveChains && len(configErr.Problems) == 0 {
+		o.checkDangling(configErr, entries, indexes)
+		o.resolveChains(configErr, entries, indexes)
+	}
+	if err := configErr.err(); err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+	return entries, nil
+}
+
+// expandUrls returns a copy of urls with environment variables
+// expanded in every url.
+func (o Options) expandUrls(urls ShortenedUrls) (ShortenedUrls, error) {
+	lookup := o.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	configErr := &ConfigError{}
+	expanded := make(ShortenedUrls, len(urls))
+	for i, entry := range urls {
+		expand := func(name string) string {
+			value, found := lookup(name)
+			if !found && o.StrictEnv {
+				configErr.add(i, entry.Path, "variable '%s' is not set", name)
+			}
+			return value
+		}
+		entry.Url = os.Expand(entry.Url, expand)
+		entry.Targets = slices.Clone(entry.Targets)
+		for j := range entry.Targets {
+			entry.Targets[j].Url = os.Expand(entry.Targets[j].Url, expand)
+		}
+		expanded[i] = entry
+	}
+	if err := configErr.err(); err != nil {
+		return nil, err
+	}
+	return expanded, nil
+}
+
+// appendQuery adds rawQuery to the query string of url, keeping
+// any fragment at the end.
+func appendQuery(url, rawQuery string) string {
+	url, fragment, hasFragment := strings.Cut(url, "#")
+	switch {
+	case strings.HasSuffix(url, "?"), strings.HasSuffix(url, "&"):
+		url += rawQuery
+	case strings.Contains(url, "?"):
+		url += "&" + rawQuery
+	default:
+		url += "?" + rawQuery
+	}
+	if hasFragment {
+		url += "#" + fragment
+	}
+	return url
+}
+
+// status returns the status to redirect to entry with.
+func (o Options) status(entry ShortenedUrl) int {
+	if entry.Status != 0 {
+		return entry.Status
+	}
+	return o.Status
+}
+
+func validateStatus(status int) error {
+	switch status {
+	case http.StatusMovedPermanently,
+		http.StatusFound,
+		http.StatusTemporaryRedirect,
+		http.StatusPermanentRedirect:
+		return nil
+	}
+	return fmt.Errorf("invalid redirect status: %d", status)
+}
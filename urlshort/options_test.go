@@ -0,0 +1,153 @@
+package urlshort
+
+import (
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+func TestRootURL(t *testing.T) {
+	urls := ShortenedUrls{
+		{Path: "/", Url: "https://example.com/old-home"},
+		{Path: "/docs", Url: "https://example.com/docs"},
+	}
+	handler, err := UrlsHandler(urls, Options{RootURL: "https://example.com/home", Logger: discardLogger}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "root", target: "/", status: http.StatusMovedPermanently, location: "https://example.com/home"},
+		{name: "other", target: "/docs", status: http.StatusMovedPermanently, location: "https://example.com/docs"},
+		{name: "miss", target: "/missing", status: http.StatusNotFound},
+	})
+
+	handler, err = UrlsHandler(urls, Options{Logger: discardLogger}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "root entry without RootURL", target: "/", status: http.StatusMovedPermanently, location: "https://example.com/old-home"},
+	})
+}
+
+func TestUnusableLoggerFallsBackToDefault(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(discardLogger)
+
+	urls := ShortenedUrls{{Path: "/a", Url: "https://example.com/a"}}
+	for name, logger := range map[string]*slog.Logger{
+		"nil":  nil,
+		"zero": {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			handler, err := UrlsHandler(urls, Options{Logger: logger}, http.NotFoundHandler())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRedirects(t, handler, []redirectCase{
+				{name: "redirect", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+				{name: "miss", target: "/b", status: http.StatusNotFound},
+			})
+		})
+	}
+}
+
+func TestCanonicalRedirect(t *testing.T) {
+	urls := ShortenedUrls{{Path: "/docs/", Url: "https://example.com/docs"}}
+	opts := Options{
+		CaseInsensitive:   true,
+		TrailingSlash:     TrailingSlashAdd,
+		CanonicalRedirect: true,
+		Logger:            discardLogger,
+	}
+	handler, err := UrlsHandler(urls, opts, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "normalized", target: "/docs/", status: http.StatusMovedPermanently, location: "https://example.com/docs"},
+		{name: "case", target: "/Docs/", status: http.StatusMovedPermanently, location: "/docs/"},
+		{name: "slash", target: "/docs", status: http.StatusMovedPermanently, location: "/docs/"},
+		{name: "query kept", target: "/DOCS?lang=en", status: http.StatusMovedPermanently, location: "/docs/?lang=en"},
+		{name: "miss", target: "/Missing", status: http.StatusNotFound},
+	})
+}
+
+func TestDefaultScheme(t *testing.T) {
+	urls := ShortenedUrls{
+		{Path: "/cdn", Url: "//cdn.example.com/x"},
+		{Path: "/abs", Url: "http://example.org/y"},
+	}
+	tests := []struct {
+		name  string
+		opts  Options
+		cases []redirectCase
+	}{
+		{"unset", Options{}, []redirectCase{
+			{name: "scheme-relative", target: "/cdn", status: http.StatusMovedPermanently, location: "//cdn.example.com/x"},
+			{name: "absolute", target: "/abs", status: http.StatusMovedPermanently, location: "http://example.org/y"},
+		}},
+		{"https", Options{DefaultScheme: "https"}, []redirectCase{
+			{name: "scheme-relative", target: "/cdn", status: http.StatusMovedPermanently, location: "https://cdn.example.com/x"},
+			{name: "absolute", target: "/abs", status: http.StatusMovedPermanently, location: "http://example.org/y"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Logger = discardLogger
+			handler, err := UrlsHandler(urls, tt.opts, http.NotFoundHandler())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRedirects(t, handler, tt.cases)
+		})
+	}
+
+	if _, err := UrlsHandler(urls, Options{ValidateURLs: true}, nil); err == nil {
+		t.Error("ValidateURLs without DefaultScheme: got no error for a scheme-relative url")
+	}
+	if _, err := UrlsHandler(urls, Options{ValidateURLs: true, DefaultScheme: "https"}, nil); err != nil {
+		t.Errorf("ValidateURLs with DefaultScheme: %v", err)
+	}
+	if _, err := UrlsHandler(urls, Options{DefaultScheme: "ftp"}, nil); err == nil {
+		t.Error("DefaultScheme ftp: got no error")
+	}
+}
+
+func TestStripSuffixes(t *testing.T) {
+	urls := ShortenedUrls{
+		{Path: "/page", Url: "https://example.com/page"},
+		{Path: "/legacy.php", Url: "https://example.com/legacy"},
+	}
+	tests := []struct {
+		name  string
+		opts  Options
+		cases []redirectCase
+	}{
+		{"strip", Options{StripSuffixes: []string{"/index.html", ".php"}}, []redirectCase{
+			{name: "index", target: "/page/index.html", status: http.StatusMovedPermanently, location: "https://example.com/page"},
+			{name: "php", target: "/page.php", status: http.StatusMovedPermanently, location: "https://example.com/page"},
+			{name: "stripped key", target: "/legacy", status: http.StatusMovedPermanently, location: "https://example.com/legacy"},
+			{name: "plain", target: "/page", status: http.StatusMovedPermanently, location: "https://example.com/page"},
+			{name: "root index", target: "/index.html", status: http.StatusNotFound},
+		}},
+		{"canonical", Options{StripSuffixes: []string{"/index.html"}, CanonicalRedirect: true}, []redirectCase{
+			{name: "index", target: "/page/index.html?q=1", status: http.StatusMovedPermanently, location: "/page?q=1"},
+			{name: "plain", target: "/page", status: http.StatusMovedPermanently, location: "https://example.com/page"},
+		}},
+		{"folded", Options{StripSuffixes: []string{"/Índex.HTML"}, CaseInsensitive: true, AccentInsensitive: true}, []redirectCase{
+			{name: "index", target: "/page/index.html", status: http.StatusMovedPermanently, location: "https://example.com/page"},
+			{name: "as listed", target: "/Page/%C3%8Dndex.HTML", status: http.StatusMovedPermanently, location: "https://example.com/page"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Logger = discardLogger
+			handler, err := UrlsHandler(urls, tt.opts, http.NotFoundHandler())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRedirects(t, handler, tt.cases)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// captureName matches a :name placeholder in a url template.
+var captureName = regexp.MustCompile(`:[A-Za-z_][A-Za-z0-9_]*`)
+
+type pathPattern struct {
+	key      string
+	segments []string
+	url      string
+	literals int
+}
+
+type patternRedirector struct {
+	redirector
+	entries  map[string]ShortenedUrl
+	patterns []pathPattern
+}
+
+// PatternHandler works like MapHandler, but also supports keys
+// with named segments, written as ":name". A named segment
+// matches exactly one segment of the request path, and every
+// ":name" in the url is replaced by the segment it matched, so
+// with the mapping
+//
+//	"/u/:id/posts/:post": "https://example.com/users/:id?post=:post"
+//
+// a request for /u/42/posts/7 is redirected to
+// https://example.com/users/42?post=7. Captured segments are
+// escaped before being substituted.
+//
+// Unlike the keys of PrefixHandler, which capture the whole
+// rest of the path, a named segment never spans a "/". Keys
+// without named segments take priority. Among patterns, the
+// one with the most literal segments wins, and ties are broken
+// by comparing the keys.
+//
+// An error is returned if a key uses the same name twice, or
+// if keys without named segments conflict, such as two naming
+// the same path once decoded, as with MapHandlerWithOptions.
+func PatternHandler(pathsToUrls map[string]string, fallback http.Handler) (http.Handler, error) {
+	opts, _ := Options{}.withDefaults()
+	pr := &patternRedirector{
+		redirector: redirector{opts: opts, fallback: fallback},
+	}
+	exact := make(map[string]string, len(pathsToUrls))
+	for key, url := range pathsToUrls {
+		if !strings.Contains(key, "/:") {
+			exact[key] = url
+			continue
+		}
+		pattern, err := compilePattern(key, url)
+		if err != nil {
+			return nil, err
+		}
+		pr.patterns = append(pr.patterns, pattern)
+	}
+	entries, err := opts.buildEntries(urlsFromMap(exact))
+	if err != nil {
+		return nil, err
+	}
+	pr.entries = entries
+	sort.Slice(pr.patterns, func(i, j int) bool {
+		if pr.patterns[i].literals != pr.patterns[j].literals {
+			return pr.patterns[i].literals > pr.patterns[j].literals
+		}
+		return pr.patterns[i].key < pr.patterns[j].key
+	})
+	return pr, nil
+}
+
+func compilePattern(key, url string) (pathPattern, error) {
+	pattern := pathPattern{key: key, segments: strings.Split(key, "/"), url: url}
+	names := map[string]bool{}
+	for i, segment := range pattern.segments {
+		name, isCapture := strings.CutPrefix(segment, ":")
+		if !isCapture {
+			pattern.segments[i] = unescapePath(segment)
+			pattern.literals++
+			continue
+		}
+		if name == "" || names[name] {
+			return pathPattern{}, fmt.Errorf("pattern '%s': invalid or repeated segment name '%s'", key, segment)
+		}
+		names[name] = true
+	}
+	return pattern, nil
+}
+
+// match returns the url for path if it matches the pattern.
+func (p pathPattern) match(path string) (string, bool) {
+	segments := strings.Split(path, "/")
+	if len(segments) != len(p.segments) {
+		return "", false
+	}
+	captures := map[string]string{}
+	for i, segment := range p.segments {
+		if name, isCapture := strings.CutPrefix(segment, ":"); isCapture {
+			if segments[i] == "" {
+				return "", false
+			}
+			captures[name] = segments[i]
+			continue
+		}
+		if segment != segments[i] {
+			return "", false
+		}
+	}
+	return captureName.ReplaceAllStringFunc(p.url, func(placeholder string) string {
+		if value, captured := captures[placeholder[1:]]; captured {
+			return url.PathEscape(value)
+		}
+		return placeholder
+	}), true
+}
+
+func (pr *patternRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pr.serve(w, r, pr.lookup)
+}
+
+// Match implements Matcher.
+func (pr *patternRedirector) Match(r *http.Request) (string, bool) {
+	return pr.matchRequest(r, pr.lookup)
+}
+
+func (pr *patternRedirector) lookup(key string) (ShortenedUrl, bool) {
+	if entry, exists := pr.entries[key]; exists {
+		return entry, true
+	}
+	for _, pattern := range pr.patterns {
+		if url, matched := pattern.match(key); matched {
+			return ShortenedUrl{Path: pattern.key, Url: url}, true
+		}
+	}
+	return ShortenedUrl{}, false
+}
@@ -0,0 +1,55 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPatternHandler(t *testing.T) {
+	handler, err := PatternHandler(map[string]string{
+		"/u/:id/posts/:post": "https://example.com/users/:id?post=:post",
+		"/u/:id":             "https://example.com/users/:id",
+		"/u/me":              "https://example.com/me",
+	}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/u/42/posts/7", http.StatusMovedPermanently, "https://example.com/users/42?post=7"},
+		{"/u/42", http.StatusMovedPermanently, "https://example.com/users/42"},
+		{"/u/me", http.StatusMovedPermanently, "https://example.com/me"},
+		{"/u/a%20b", http.StatusMovedPermanently, "https://example.com/users/a%20b"},
+		{"/u/42/posts", http.StatusNotFound, ""},
+		{"/u/", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+		})
+	}
+}
+
+func TestPatternHandlerDecodedCollision(t *testing.T) {
+	_, err := PatternHandler(map[string]string{
+		"/caf%C3%A9": "https://first.example.com",
+		"/café":      "https://second.example.com",
+	}, nil)
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("got %v, want a ConfigError", err)
+	}
+}
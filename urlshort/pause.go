@@ -0,0 +1,50 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// pauser lets a handler be switched into maintenance, during
+// which every request skips the mappings. Checking it costs a
+// single atomic load.
+type pauser struct {
+	maintenance atomic.Pointer[maintenance]
+}
+
+type maintenance struct {
+	handler http.Handler
+}
+
+// Pause makes every request go to handler, without looking at
+// the mappings, until Resume is called. If handler is nil, the
+// fallback of the handler is used instead. Pausing an already
+// paused handler replaces the maintenance handler.
+func (p *pauser) Pause(handler http.Handler) {
+	p.maintenance.Store(&maintenance{handler: handler})
+}
+
+// Resume makes requests be served from the mappings again.
+func (p *pauser) Resume() {
+	p.maintenance.Store(nil)
+}
+
+// Paused reports whether the handler is paused.
+func (p *pauser) Paused() bool {
+	return p.maintenance.Load() != nil
+}
+
+// servePaused serves r with the maintenance handler, or with
+// fallback if there is none, and returns true if paused.
+func (p *pauser) servePaused(w http.ResponseWriter, r *http.Request, fallback http.Handler) bool {
+	m := p.maintenance.Load()
+	if m == nil {
+		return false
+	}
+	if m.handler != nil {
+		m.handler.ServeHTTP(w, r)
+	} else {
+		fallback.ServeHTTP(w, r)
+	}
+	return true
+}
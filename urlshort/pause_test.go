@@ -0,0 +1,44 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPauseAndResume(t *testing.T) {
+	maintenance := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	})
+	reloadable, err := NewReloadableYAMLHandler([]byte("- path: /a\n  url: https://example.com/a\n"), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlers := map[string]interface {
+		http.Handler
+		Pause(http.Handler)
+		Resume()
+	}{
+		"MutableHandler":    NewMutableHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler()),
+		"ReloadableHandler": reloadable,
+	}
+	for name, handler := range handlers {
+		t.Run(name, func(t *testing.T) {
+			redirect := redirectCase{name: "redirect", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"}
+			checkRedirects(t, handler, []redirectCase{redirect})
+
+			handler.Pause(maintenance)
+			checkRedirects(t, handler, []redirectCase{
+				{name: "paused", target: "/a", status: http.StatusServiceUnavailable},
+				{name: "paused miss", target: "/b", status: http.StatusServiceUnavailable},
+			})
+
+			handler.Pause(nil)
+			checkRedirects(t, handler, []redirectCase{
+				{name: "paused to fallback", target: "/a", status: http.StatusNotFound},
+			})
+
+			handler.Resume()
+			checkRedirects(t, handler, []redirectCase{redirect})
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package urlshort
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ProtoHandler will parse the provided protobuf and then return
+// an http.Handler that will attempt to map any paths to their
+// corresponding URL. If the path is not provided in the
+// protobuf, then the fallback http.Handler will be called
+// instead.
+//
+// The protobuf is expected to be a ShortenedUrls message in the
+// binary wire format, as defined in urlshort.proto next to this
+// file, whose fields mirror those of ShortenedUrl:
+//
+//	message ShortenedUrls {
+//	  repeated ShortenedUrl urls = 1;
+//	}
+//
+//	message ShortenedUrl {
+//	  string path = 1;
+//	  string url = 2;
+//	  ...
+//	}
+//
+// Fields unknown to this package are skipped, so configs
+// written with a newer schema still load.
+//
+// The only errors that can be returned all related to having
+// invalid protobuf data or a path that points to two different
+// urls.
+func ProtoHandler(protoInput []byte, fallback http.Handler) (http.Handler, error) {
+	handler, _, err := ProtoHandlerWithUrls(protoInput, fallback)
+	return handler, err
+}
+
+// ProtoHandlerWithUrls works like ProtoHandler, but also
+// returns the ShortenedUrls parsed from the protobuf.
+func ProtoHandlerWithUrls(protoInput []byte, fallback http.Handler) (http.Handler, ShortenedUrls, error) {
+	parsedProto, err := parseProto(protoInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := UrlsHandler(parsedProto, Options{}, fallback)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, parsedProto, nil
+}
+
+func parseProto(protoInput []byte) (ShortenedUrls, error) {
+	protoInput, err := prepareInput(protoInput)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls ShortenedUrls
+	err = decodeProto(protoInput, func(num protowire.Number, value protoValue) error {
+		if num != 1 {
+			return nil
+		}
+		var entry ShortenedUrl
+		if err := decodeProto(value.bytes, entry.decodeProtoField); err != nil {
+			return fmt.Errorf("urls[%d]: %w", len(urls), err)
+		}
+		urls = append(urls, entry)
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("proto: %w", err)
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// protoValue is a field value read off the wire: bytes for
+// length-delimited fields, and number for the others.
+type protoValue struct {
+	typ    protowire.Type
+	bytes  []byte
+	number uint64
+}
+
+// decodeProto calls field with each field of the message in
+// input, in the order they appear, skipping groups.
+func decodeProto(input []byte, field func(protowire.Number, protoValue) error) error {
+	for len(input) > 0 {
+		num, typ, n := protowire.ConsumeTag(input)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		input = input[n:]
+		value := protoValue{typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			value.number, n = protowire.ConsumeVarint(input)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(input)
+			value.number = uint64(v)
+		case protowire.Fixed64Type:
+			value.number, n = protowire.ConsumeFixed64(input)
+		case protowire.BytesType:
+			value.bytes, n = protowire.ConsumeBytes(input)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, input)
+		}
+		if n < 0 {
+			return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+		}
+		input = input[n:]
+		if typ == protowire.StartGroupType {
+			continue
+		}
+		if err := field(num, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expect returns an error unless the value has the wire type
+// of the field.
+func (v protoValue) expect(name string, typ protowire.Type) error {
+	if v.typ != typ {
+		return fmt.Errorf("field '%s' has wire type %d, expected %d", name, v.typ, typ)
+	}
+	return nil
+}
+
+func (v protoValue) string(name string) (string, error) {
+	return string(v.bytes), v.expect(name, protowire.BytesType)
+}
+
+func (v protoValue) bool(name string) (bool, error) {
+	return v.number != 0, v.expect(name, protowire.VarintType)
+}
+
+// timestamp decodes a google.protobuf.Timestamp.
+func (v protoValue) timestamp(name string) (*time.Time, error) {
+	if err := v.expect(name, protowire.BytesType); err != nil {
+		return nil, err
+	}
+	var seconds, nanos int64
+	err := decodeProto(v.bytes, func(num protowire.Number, field protoValue) error {
+		switch num {
+		case 1:
+			seconds = int64(field.number)
+			return field.expect(name+".seconds", protowire.VarintType)
+		case 2:
+			nanos = int64(int32(field.number))
+			return field.expect(name+".nanos", protowire.VarintType)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	t := time.Unix(seconds, nanos).UTC()
+	return &t, nil
+}
+
+// mapEntry decodes an entry of a map<string, string> into m,
+// allocating it if needed.
+func (v protoValue) mapEntry(name string, m *map[string]string) error {
+	if err := v.expect(name, protowire.BytesType); err != nil {
+		return err
+	}
+	var key, value string
+	err := decodeProto(v.bytes, func(num protowire.Number, field protoValue) (err error) {
+		switch num {
+		case 1:
+			key, err = field.string(name + ".key")
+		case 2:
+			value, err = field.string(name + ".value")
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if *m == nil {
+		*m = map[string]string{}
+	}
+	(*m)[key] = value
+	return nil
+}
+
+func (t *Target) decodeProtoField(num protowire.Number, value protoValue) (err error) {
+	switch num {
+	case 1:
+		t.Url, err = value.string("url")
+	case 2:
+		t.Weight = math.Float64frombits(value.number)
+		err = value.expect("weight", protowire.Fixed64Type)
+	}
+	return err
+}
+
+func (su *ShortenedUrl) decodeProtoField(num protowire.Number, value protoValue) (err error) {
+	switch num {
+	case 1:
+		su.Path, err = value.string("path")
+	case 2:
+		su.Url, err = value.string("url")
+	case 3:
+		var path string
+		path, err = value.string("paths")
+		su.Paths = append(su.Paths, path)
+	case 4:
+		su.ExpiresAt, err = value.timestamp("expires_at")
+	case 5:
+		su.NotBefore, err = value.timestamp("not_before")
+	case 6:
+		su.Status = int(int32(value.number))
+		err = value.expect("status", protowire.VarintType)
+	case 7:
+		var method string
+		method, err = value.string("methods")
+		su.Methods = append(su.Methods, method)
+	case 8:
+		su.Description, err = value.string("description")
+	case 9:
+		if err = value.expect("targets", protowire.BytesType); err != nil {
+			return err
+		}
+		var target Target
+		if err = decodeProto(value.bytes, target.decodeProtoField); err != nil {
+			return fmt.Errorf("targets[%d]: %w", len(su.Targets), err)
+		}
+		su.Targets = append(su.Targets, target)
+	case 10:
+		su.Gone, err = value.bool("gone")
+	case 11:
+		err = value.mapEntry("query", &su.Query)
+	case 12:
+		err = value.mapEntry("headers", &su.Headers)
+	case 13:
+		su.Proxy, err = value.bool("proxy")
+	case 14:
+		su.Interstitial, err = value.bool("interstitial")
+	case 15:
+		var enabled bool
+		enabled, err = value.bool("enabled")
+		su.Enabled = &enabled
+	case 16:
+		su.MaxHits = int64(value.number)
+		err = value.expect("max_hits", protowire.VarintType)
+	case 17:
+		err = value.mapEntry("match_headers", &su.MatchHeaders)
+	}
+	return err
+}
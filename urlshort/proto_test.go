@@ -0,0 +1,157 @@
+package urlshort
+
+import (
+	"math"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// protoMessage builds a message in the protobuf wire format.
+type protoMessage []byte
+
+func (m protoMessage) string(num protowire.Number, s string) protoMessage {
+	m = protowire.AppendTag(m, num, protowire.BytesType)
+	return protowire.AppendString(m, s)
+}
+
+func (m protoMessage) message(num protowire.Number, field protoMessage) protoMessage {
+	m = protowire.AppendTag(m, num, protowire.BytesType)
+	return protowire.AppendBytes(m, field)
+}
+
+func (m protoMessage) varint(num protowire.Number, v uint64) protoMessage {
+	m = protowire.AppendTag(m, num, protowire.VarintType)
+	return protowire.AppendVarint(m, v)
+}
+
+func (m protoMessage) fixed64(num protowire.Number, v uint64) protoMessage {
+	m = protowire.AppendTag(m, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(m, v)
+}
+
+func (m protoMessage) mapEntry(num protowire.Number, key, value string) protoMessage {
+	return m.message(num, protoMessage{}.string(1, key).string(2, value))
+}
+
+func TestParseProto(t *testing.T) {
+	expiry := time.Date(2030, 1, 2, 3, 4, 5, 6, time.UTC)
+	full := protoMessage{}.
+		string(1, "/a").
+		string(2, "https://example.com/a").
+		string(3, "/b").
+		string(3, "/c").
+		message(4, protoMessage{}.varint(1, uint64(expiry.Unix())).varint(2, uint64(expiry.Nanosecond()))).
+		varint(6, http.StatusFound).
+		string(7, http.MethodGet).
+		string(7, http.MethodHead).
+		string(8, "described").
+		mapEntry(11, "utm", "abc").
+		mapEntry(11, "ref", "mail").
+		mapEntry(12, "X-A", "1").
+		varint(15, 0).
+		varint(16, 3).
+		mapEntry(17, "Accept", "text/html")
+	weighted := protoMessage{}.
+		string(1, "/w").
+		message(9, protoMessage{}.string(1, "https://a.example.com").fixed64(2, math.Float64bits(2))).
+		message(9, protoMessage{}.string(1, "https://b.example.com").fixed64(2, math.Float64bits(0.5))).
+		varint(10, 1)
+	input := protoMessage{}.message(1, full).message(1, weighted)
+
+	urls, err := parseProto(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disabled := false
+	want := ShortenedUrls{
+		{
+			Path:         "/a",
+			Url:          "https://example.com/a",
+			Paths:        []string{"/b", "/c"},
+			ExpiresAt:    &expiry,
+			Status:       http.StatusFound,
+			Methods:      []string{http.MethodGet, http.MethodHead},
+			Description:  "described",
+			Query:        map[string]string{"utm": "abc", "ref": "mail"},
+			Headers:      map[string]string{"X-A": "1"},
+			Enabled:      &disabled,
+			MaxHits:      3,
+			MatchHeaders: map[string]string{"Accept": "text/html"},
+		},
+		{
+			Path: "/w",
+			Targets: []Target{
+				{Url: "https://a.example.com", Weight: 2},
+				{Url: "https://b.example.com", Weight: 0.5},
+			},
+			Gone: true,
+		},
+	}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("parseProto() = %+v, want %+v", urls, want)
+	}
+}
+
+func TestParseProtoUnknownFields(t *testing.T) {
+	entry := protoMessage{}.
+		string(1, "/a").
+		varint(99, 7).
+		string(2, "https://example.com/a").
+		fixed64(100, 1)
+	entry = protowire.AppendTag(entry, 101, protowire.Fixed32Type)
+	entry = protowire.AppendFixed32(entry, 1)
+	entry = protowire.AppendTag(entry, 102, protowire.StartGroupType)
+	entry = protoMessage(entry).string(1, "inside a group")
+	entry = protowire.AppendTag(entry, 102, protowire.EndGroupType)
+	input := protoMessage{}.varint(2, 1).message(1, entry).string(3, "top level")
+
+	handler, urls, err := ProtoHandlerWithUrls(input, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ShortenedUrls{{Path: "/a", Url: "https://example.com/a"}}); !reflect.DeepEqual(urls, want) {
+		t.Errorf("urls = %+v, want %+v", urls, want)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "miss", target: "/b", status: http.StatusNotFound},
+	})
+}
+
+func TestParseProtoErrors(t *testing.T) {
+	entry := protoMessage{}.string(1, "/a").string(2, "https://example.com/a")
+	for _, tc := range []struct {
+		name  string
+		input []byte
+	}{
+		{name: "truncated tag", input: []byte{0x80}},
+		{name: "truncated length", input: protowire.AppendTag(nil, 1, protowire.BytesType)},
+		{name: "truncated message", input: protoMessage{}.message(1, entry)[:len(entry)]},
+		{name: "truncated field", input: protoMessage{}.message(1, entry[:len(entry)-3])},
+		{name: "truncated varint", input: protoMessage{}.message(1, protoMessage{}.varint(6, 301)[:2])},
+		{name: "status as bytes", input: protoMessage{}.message(1, protoMessage{}.string(6, "301"))},
+		{name: "packed methods", input: protoMessage{}.message(1, protoMessage{}.varint(7, 1))},
+		{name: "map entry as varint", input: protoMessage{}.message(1, protoMessage{}.varint(11, 1))},
+		{name: "weight as varint", input: protoMessage{}.message(1, protoMessage{}.message(9, protoMessage{}.varint(2, 1)))},
+		{name: "unterminated group", input: protowire.AppendTag(nil, 5, protowire.StartGroupType)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if urls, err := parseProto(tc.input); err == nil {
+				t.Errorf("parseProto() = %+v, want an error", urls)
+			}
+		})
+	}
+}
+
+func TestProtoHandlerDuplicate(t *testing.T) {
+	input := protoMessage{}.
+		message(1, protoMessage{}.string(1, "/a").string(2, "https://example.com/1")).
+		message(1, protoMessage{}.string(1, "/a").string(2, "https://example.com/2"))
+	if _, err := ProtoHandler(input, http.NotFoundHandler()); err == nil {
+		t.Error("ProtoHandler() = nil error, want a duplicate path")
+	}
+}
@@ -0,0 +1,47 @@
+package urlshort
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// ProxyHandler works like MapHandler, but serves the content of
+// each url through a reverse proxy instead of redirecting to
+// it. See Options.Proxy for how requests are proxied.
+func ProxyHandler(pathsToUrls map[string]string, fallback http.Handler) (http.Handler, error) {
+	return MapHandlerWithOptions(pathsToUrls, Options{Proxy: true}, fallback)
+}
+
+// proxy serves r with the response of rawUrl, adding headers
+// to it.
+func (rd *redirector) proxy(w http.ResponseWriter, r *http.Request, rawUrl string, headers map[string]string) {
+	target, err := url.Parse(rawUrl)
+	if err != nil || !target.IsAbs() || target.Host == "" {
+		rd.log(r, slog.LevelError, "Cannot proxy to url",
+			slog.String("url", rawUrl))
+		rd.error(w, r, http.StatusBadGateway)
+		return
+	}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL = target
+			pr.Out.Host = target.Host
+			pr.SetXForwarded()
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			for name, value := range headers {
+				resp.Header.Set(name, value)
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			rd.log(r, slog.LevelError, "Error while proxying",
+				slog.String("url", rawUrl),
+				slog.Any("error", err))
+			rd.error(w, r, http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
@@ -0,0 +1,104 @@
+package urlshort
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// upstream starts a server answering with the method, path,
+// query and forwarding headers of each request, closed when the
+// test ends.
+func upstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Upstream", "yes")
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, r.Method+" "+r.URL.RequestURI()+" "+string(body)+
+			" host="+r.Host+
+			" xff="+r.Header.Get("X-Forwarded-For")+
+			" xfh="+r.Header.Get("X-Forwarded-Host")+
+			" connection="+r.Header.Get("Connection"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProxyHandler(t *testing.T) {
+	server := upstream(t)
+	handler, err := ProxyHandler(map[string]string{"/doc": server.URL + "/docs/page?lang=en"}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "http://short.example.com/doc", strings.NewReader("payload"))
+	r.Header.Set("Connection", "X-Hop")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, r)
+
+	if rr.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want the upstream status %d", rr.Code, http.StatusTeapot)
+	}
+	if rr.Header().Get("X-Upstream") != "yes" {
+		t.Error("upstream header not passed on")
+	}
+	if rr.Header().Get("Location") != "" {
+		t.Error("proxied response has a Location header")
+	}
+	body := rr.Body.String()
+	host := strings.TrimPrefix(server.URL, "http://")
+	for _, want := range []string{
+		"POST /docs/page?lang=en payload",
+		"host=" + host,
+		"xff=192.0.2.1",
+		"xfh=short.example.com",
+		"connection= ",
+	} {
+		if !strings.Contains(body+" ", want) {
+			t.Errorf("upstream saw %q, want %q in it", body, want)
+		}
+	}
+
+	checkRedirects(t, handler, []redirectCase{
+		{name: "miss", target: "/other", status: http.StatusNotFound},
+	})
+}
+
+func TestProxyEntryHeaders(t *testing.T) {
+	server := upstream(t)
+	handler, err := UrlsHandler(ShortenedUrls{
+		{Path: "/proxied", Url: server.URL + "/p", Proxy: true, Headers: map[string]string{"X-Entry": "1"}},
+		{Path: "/redirected", Url: server.URL + "/r"},
+	}, Options{}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := serveCase(handler, redirectCase{target: "/proxied"})
+	if rr.Code != http.StatusTeapot || rr.Header().Get("X-Entry") != "1" {
+		t.Errorf("proxied entry = %d with X-Entry %q, want %d with 1", rr.Code, rr.Header().Get("X-Entry"), http.StatusTeapot)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "other entries redirect", target: "/redirected", status: http.StatusMovedPermanently, location: server.URL + "/r"},
+	})
+}
+
+func TestProxyUnreachable(t *testing.T) {
+	// Take a free port and close it, so that nothing listens there.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	handler, err := ProxyHandler(map[string]string{"/down": "http://" + addr + "/"}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "bad gateway", target: "/down", status: http.StatusBadGateway},
+	})
+}
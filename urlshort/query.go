@@ -0,0 +1,151 @@
+package urlshort
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+)
+
+type queryRedirector struct {
+	redirector
+	rules   map[string]ShortenedUrls
+	entries map[string]ShortenedUrl
+}
+
+// QueryHandler works like UrlsHandler, but also matches on the
+// query parameters of the request, so that /download?os=mac
+// and /download?os=win can redirect to different urls:
+//
+//   - path: /download
+//     query: {os: mac}
+//     url: https://example.com/app.dmg
+//   - path: /download
+//     query: {os: win}
+//     url: https://example.com/app.exe
+//   - path: /download
+//     url: https://example.com/downloads
+//
+// An entry with Query matches when the request has every one
+// of its parameters with the value given; other parameters are
+// ignored. Entries can match on request headers the same way
+// with MatchHeaders, header names being case-insensitive, so
+// that a tenant header can pick the url:
+//
+//   - path: /x
+//     match_headers: {X-Tenant: a}
+//     url: https://a.example.com/x
+//   - path: /x
+//     match_headers: {X-Tenant: b}
+//     url: https://b.example.com/x
+//
+// Entries with Query or MatchHeaders are tried in the order
+// they are listed, and the first one that matches wins. When
+// none of them match, such as for a request without the header,
+// the entry for the path without either is used, if there is
+// one, and otherwise the fallback http.Handler will be called.
+//
+// Every entry is checked as UrlsHandler checks them, and an
+// entry with Query or MatchHeaders is tried for each of its
+// paths and aliases. The only errors that can be returned are
+// those UrlsHandler returns.
+func QueryHandler(urls ShortenedUrls, fallback http.Handler) (http.Handler, error) {
+	opts, _ := Options{}.withDefaults()
+	qr := &queryRedirector{
+		redirector: redirector{opts: opts, fallback: fallback},
+		rules:      map[string]ShortenedUrls{},
+	}
+	urls, err := opts.checkEntries(urls)
+	if err != nil {
+		return nil, err
+	}
+	var plain ShortenedUrls
+	for _, entry := range urls {
+		if len(entry.Query) == 0 && len(entry.MatchHeaders) == 0 {
+			plain = append(plain, entry)
+			continue
+		}
+		if entry.MaxHits > 0 {
+			entry.hits = new(atomic.Int64)
+		}
+		for _, path := range entryPaths(entry) {
+			rule := entry
+			rule.Path = path
+			key := opts.entryKey(path)
+			qr.rules[key] = append(qr.rules[key], rule)
+		}
+	}
+	entries, err := opts.buildEntries(plain)
+	if err != nil {
+		return nil, err
+	}
+	qr.entries = entries
+	return qr, nil
+}
+
+// checkEntries runs the checks buildEntries runs on each entry
+// on its own over every entry in urls, so that problems are
+// reported with their index in urls, and returns the entries
+// left once disallowed hosts are skipped.
+func (o Options) checkEntries(urls ShortenedUrls) (ShortenedUrls, error) {
+	if o.allowedHosts != nil {
+		allowed, err := o.checkHosts(urls)
+		if err != nil {
+			return nil, err
+		}
+		urls = allowed
+	}
+	configErr := &ConfigError{}
+	checkTargets(configErr, urls)
+	checkStatuses(configErr, urls)
+	checkMaxHits(configErr, urls)
+	if err := configErr.err(); err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+	return urls, nil
+}
+
+func (qr *queryRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	qr.serve(w, r, qr.lookup(r))
+}
+
+// Match implements Matcher.
+func (qr *queryRedirector) Match(r *http.Request) (string, bool) {
+	return qr.matchRequest(r, qr.lookup(r))
+}
+
+func (qr *queryRedirector) lookup(r *http.Request) lookupFunc {
+	query := r.URL.Query()
+	return func(key string) (ShortenedUrl, bool) {
+		for _, rule := range qr.rules[key] {
+			if qr.active(rule) && queryMatches(rule.Query, query) && headersMatch(rule.MatchHeaders, r.Header) {
+				return rule, true
+			}
+		}
+		entry, exists := qr.entries[key]
+		return entry, exists
+	}
+}
+
+// queryMatches reports whether query has every parameter in
+// want with the value given.
+func queryMatches(want map[string]string, query url.Values) bool {
+	for name, value := range want {
+		if !query.Has(name) || query.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// headersMatch reports whether header has every header in want
+// with the value given.
+func headersMatch(want map[string]string, header http.Header) bool {
+	for name, value := range want {
+		if values := header.Values(name); len(values) == 0 || values[0] != value {
+			return false
+		}
+	}
+	return true
+}
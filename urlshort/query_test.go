@@ -0,0 +1,76 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryHandler(t *testing.T) {
+	urls := ShortenedUrls{
+		{Path: "/download", Query: map[string]string{"os": "mac"}, Url: "https://example.com/app.dmg", Paths: []string{"/dl"}},
+		{Path: "/download", Query: map[string]string{"os": "win"}, Url: "https://example.com/app.exe", Status: http.StatusFound},
+		{Path: "/download", Url: "https://example.com/downloads"},
+		{Path: "/x", MatchHeaders: map[string]string{"X-Tenant": "a"}, Url: "https://a.example.com/x"},
+	}
+	handler, err := QueryHandler(urls, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		header   http.Header
+		status   int
+		location string
+	}{
+		{"query", "/download?os=mac", nil, http.StatusMovedPermanently, "https://example.com/app.dmg"},
+		{"query status", "/download?os=win", nil, http.StatusFound, "https://example.com/app.exe"},
+		{"no query", "/download", nil, http.StatusMovedPermanently, "https://example.com/downloads"},
+		{"alias", "/dl?os=mac", nil, http.StatusMovedPermanently, "https://example.com/app.dmg"},
+		{"alias without query", "/dl", nil, http.StatusNotFound, ""},
+		{"header", "/x", http.Header{"X-Tenant": {"a"}}, http.StatusMovedPermanently, "https://a.example.com/x"},
+		{"other header", "/x", http.Header{"X-Tenant": {"b"}}, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for name, values := range tt.header {
+				r.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+		})
+	}
+}
+
+func TestQueryHandlerValidatesRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry ShortenedUrl
+	}{
+		{"status", ShortenedUrl{Path: "/a", Query: map[string]string{"q": "1"}, Url: "https://example.com", Status: http.StatusOK}},
+		{"max hits", ShortenedUrl{Path: "/a", MatchHeaders: map[string]string{"X-A": "1"}, Url: "https://example.com", MaxHits: -1}},
+		{"weight", ShortenedUrl{Path: "/a", Query: map[string]string{"q": "1"}, Targets: []Target{{Url: "https://example.com", Weight: -1}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := QueryHandler(ShortenedUrls{tt.entry}, nil)
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("got %v, want a ConfigError", err)
+			}
+			if got := configErr.Problems[0].Index; got != 0 {
+				t.Errorf("problem index = %d, want 0", got)
+			}
+		})
+	}
+}
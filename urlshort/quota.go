@@ -0,0 +1,27 @@
+package urlshort
+
+// checkMaxHits records a problem for every entry with a
+// negative MaxHits.
+func checkMaxHits(configErr *ConfigError, urls ShortenedUrls) {
+	for i, entry := range urls {
+		if entry.MaxHits < 0 {
+			configErr.add(i, entry.Path, "invalid max hits %d: must not be negative", entry.MaxHits)
+		}
+	}
+}
+
+// takeHit counts a request served by the entry, and reports
+// whether it was still within MaxHits. Concurrent requests
+// never get more hits than allowed between them.
+func (u ShortenedUrl) takeHit() bool {
+	if u.MaxHits <= 0 || u.hits == nil {
+		return true
+	}
+	return u.hits.Add(1) <= u.MaxHits
+}
+
+// usedUp reports whether the entry has served all the requests
+// its MaxHits allows.
+func (u ShortenedUrl) usedUp() bool {
+	return u.MaxHits > 0 && u.hits != nil && u.hits.Load() >= u.MaxHits
+}
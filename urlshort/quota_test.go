@@ -0,0 +1,56 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMaxHits(t *testing.T) {
+	urls := ShortenedUrls{
+		{Path: "/giveaway", Url: "https://example.com/prize", MaxHits: 2, Paths: []string{"/prize"}},
+		{Path: "/open", Url: "https://example.com/open"},
+	}
+	handler, err := UrlsHandler(urls, Options{Logger: discardLogger}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "first", target: "/giveaway", status: http.StatusMovedPermanently, location: "https://example.com/prize"},
+		{name: "alias shares count", target: "/prize", status: http.StatusMovedPermanently, location: "https://example.com/prize"},
+		{name: "used up", target: "/giveaway", status: http.StatusGone},
+		{name: "alias used up", target: "/prize", status: http.StatusGone},
+		{name: "unlimited", target: "/open", status: http.StatusMovedPermanently, location: "https://example.com/open"},
+	})
+}
+
+func TestMaxHitsConcurrent(t *testing.T) {
+	urls := ShortenedUrls{{Path: "/a", Url: "https://example.com/a", MaxHits: 10}}
+	handler, err := UrlsHandler(urls, Options{Logger: discardLogger}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var redirected atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if serveCase(handler, redirectCase{target: "/a"}).Code == http.StatusMovedPermanently {
+				redirected.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := redirected.Load(); got != 10 {
+		t.Errorf("redirected %d requests, want 10", got)
+	}
+}
+
+func TestMaxHitsNegative(t *testing.T) {
+	urls := ShortenedUrls{{Path: "/a", Url: "https://example.com/a", MaxHits: -1}}
+	if _, err := UrlsHandler(urls, Options{}, nil); err == nil {
+		t.Error("got no error for a negative MaxHits")
+	}
+}
@@ -0,0 +1,114 @@
+package urlshort
+
+import (
+	"container/list"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// maxRateLimitedClients bounds the number of client IPs a
+// RateLimited handler keeps a limiter for, so that requests from
+// many addresses cannot grow it without limit.
+const maxRateLimitedClients = 10000
+
+type rateLimiter struct {
+	next     http.Handler
+	limit    rate.Limit
+	burst    int
+	ipHeader string
+
+	mu       sync.Mutex
+	limiters map[string]*list.Element
+	// recent orders the clients from most to least recently
+	// seen, holding a *clientLimiter each.
+	recent *list.List
+}
+
+type clientLimiter struct {
+	ip      string
+	limiter *rate.Limiter
+}
+
+// RateLimited returns an http.Handler that lets each client IP
+// make limit requests per second, with bursts of up to burst,
+// and passes them to next. Requests over the limit get a 429
+// Too Many Requests with a Retry-After header instead. It can
+// wrap any handler in this package, to stop a public shortener
+// from being used to flood the sites it redirects to.
+//
+// The client IP is taken from the RemoteAddr of the request.
+// When ipHeader is set, such as to X-Forwarded-For or X-Real-IP,
+// the last address in that header is used instead, which is the
+// one the closest proxy saw. Only set it when such a proxy sets
+// the header on every request, since clients can send any value
+// for it themselves.
+//
+// Only the 10000 most recently seen IPs are tracked. An IP seen
+// again after being dropped starts over with a full burst.
+func RateLimited(next http.Handler, limit rate.Limit, burst int, ipHeader string) http.Handler {
+	return &rateLimiter{
+		next:     next,
+		limit:    limit,
+		burst:    burst,
+		ipHeader: ipHeader,
+		limiters: map[string]*list.Element{},
+		recent:   list.New(),
+	}
+}
+
+func (rl *rateLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !rl.limiter(rl.clientIP(r)).Allow() {
+		retryAfter := 1
+		if rl.limit > 0 {
+			retryAfter = max(1, int(math.Ceil(1/float64(rl.limit))))
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+	rl.next.ServeHTTP(w, r)
+}
+
+// limiter returns the limiter of ip, creating it if needed.
+func (rl *rateLimiter) limiter(ip string) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if element, exists := rl.limiters[ip]; exists {
+		rl.recent.MoveToFront(element)
+		return element.Value.(*clientLimiter).limiter
+	}
+	if rl.recent.Len() >= maxRateLimitedClients {
+		oldest := rl.recent.Back()
+		rl.recent.Remove(oldest)
+		delete(rl.limiters, oldest.Value.(*clientLimiter).ip)
+	}
+	client := &clientLimiter{ip: ip, limiter: rate.NewLimiter(rl.limit, rl.burst)}
+	rl.limiters[ip] = rl.recent.PushFront(client)
+	return client.limiter
+}
+
+// clientIP returns the IP address r came from.
+func (rl *rateLimiter) clientIP(r *http.Request) string {
+	if rl.ipHeader != "" {
+		if values := r.Header.Values(rl.ipHeader); len(values) > 0 {
+			last := values[len(values)-1]
+			if i := strings.LastIndex(last, ","); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
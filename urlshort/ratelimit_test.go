@@ -0,0 +1,77 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// serveFrom serves a GET request for /a from remoteAddr, with
+// the given X-Forwarded-For values, and returns the response.
+func serveFrom(handler http.Handler, remoteAddr string, forwardedFor ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/a", nil)
+	r.RemoteAddr = remoteAddr
+	for _, value := range forwardedFor {
+		r.Header.Add("X-Forwarded-For", value)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, r)
+	return rr
+}
+
+func TestRateLimited(t *testing.T) {
+	next := MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	handler := RateLimited(next, rate.Limit(0.5), 2, "")
+
+	for i, want := range []int{http.StatusMovedPermanently, http.StatusMovedPermanently, http.StatusTooManyRequests} {
+		if rr := serveFrom(handler, "192.0.2.1:1234"); rr.Code != want {
+			t.Errorf("request %d: status = %d, want %d", i+1, rr.Code, want)
+		}
+	}
+	rr := serveFrom(handler, "192.0.2.1:5678")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("same IP, other port: status = %d, want 429", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	if rr := serveFrom(handler, "192.0.2.2:1234"); rr.Code != http.StatusMovedPermanently {
+		t.Errorf("other IP: status = %d, want 301", rr.Code)
+	}
+}
+
+func TestRateLimitedIPHeader(t *testing.T) {
+	handler := RateLimited(http.NotFoundHandler(), rate.Limit(1), 1, "X-Forwarded-For")
+	if rr := serveFrom(handler, "10.0.0.1:1", "203.0.113.9, 198.51.100.1"); rr.Code != http.StatusNotFound {
+		t.Fatalf("first request: status = %d, want 404", rr.Code)
+	}
+	// The last address is the one counted, whatever the proxy
+	// and the addresses before it.
+	if rr := serveFrom(handler, "10.0.0.2:1", "198.51.100.1"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("same last address: status = %d, want 429", rr.Code)
+	}
+	if rr := serveFrom(handler, "10.0.0.1:1", "198.51.100.1", "203.0.113.9"); rr.Code != http.StatusNotFound {
+		t.Errorf("last header value differs: status = %d, want 404", rr.Code)
+	}
+	// Without the header, RemoteAddr is used.
+	if rr := serveFrom(handler, "10.0.0.1:1"); rr.Code != http.StatusNotFound {
+		t.Errorf("no header: status = %d, want 404", rr.Code)
+	}
+}
+
+func TestRateLimitedForgetsOldestClient(t *testing.T) {
+	rl := RateLimited(http.NotFoundHandler(), rate.Limit(1), 1, "").(*rateLimiter)
+	first := rl.limiter("client-0")
+	for i := 1; i <= maxRateLimitedClients; i++ {
+		rl.limiter("client-" + strconv.Itoa(i))
+	}
+	if len(rl.limiters) != maxRateLimitedClients || rl.recent.Len() != maxRateLimitedClients {
+		t.Errorf("tracking %d clients, want %d", len(rl.limiters), maxRateLimitedClients)
+	}
+	if rl.limiter("client-0") == first {
+		t.Error("oldest client was not dropped")
+	}
+}
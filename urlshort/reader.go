@@ -0,0 +1,91 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLHandlerReader works like YAMLHandler, but decodes the
+// YAML directly from r instead of requiring it to be read into
+// memory first. Errors returned by r while decoding are
+// returned as is.
+func YAMLHandlerReader(r io.Reader, fallback http.Handler) (http.Handler, error) {
+	parsedYaml, err := decodeYAML(r)
+	if err != nil {
+		return nil, err
+	}
+	return UrlsHandler(parsedYaml, Options{}, fallback)
+}
+
+// JSONHandlerReader works like JSONHandler, but decodes the
+// JSON directly from r instead of requiring it to be read into
+// memory first. Errors returned by r while decoding are
+// returned as is.
+func JSONHandlerReader(r io.Reader, fallback http.Handler) (http.Handler, error) {
+	parsedJson, err := decodeJSON(r)
+	if err != nil {
+		return nil, err
+	}
+	return UrlsHandler(parsedJson, Options{}, fallback)
+}
+
+func decodeYAML(r io.Reader) (ShortenedUrls, error) {
+	var urls ShortenedUrls
+
+	r, err := prepareReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The YAML decoder only keeps the message of read errors, so
+	// remember the error itself to return it unchanged.
+	reader := &errorReader{r: r}
+	err = decodeSafely("yaml", func() error {
+		return yaml.NewDecoder(reader).Decode(&urls)
+	})
+	if reader.err != nil {
+		err = reader.err
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+func decodeJSON(r io.Reader) (ShortenedUrls, error) {
+	var urls ShortenedUrls
+
+	r, err := prepareReader(r)
+	if err != nil {
+		return nil, err
+	}
+	err = json.NewDecoder(r).Decode(&urls)
+	if err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// errorReader remembers the first error, other than io.EOF,
+// returned by the wrapped reader.
+type errorReader struct {
+	r   io.Reader
+	err error
+}
+
+func (er *errorReader) Read(p []byte) (int, error) {
+	n, err := er.r.Read(p)
+	if err != nil && err != io.EOF && er.err == nil {
+		er.err = err
+	}
+	return n, err
+}
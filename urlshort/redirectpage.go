@@ -0,0 +1,37 @@
+package urlshort
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// RedirectPage is the data the body of a redirect is rendered
+// with.
+type RedirectPage struct {
+	// URL is where the redirect leads to.
+	URL string
+	// Status is the HTTP status code of the redirect.
+	Status int
+}
+
+// redirectPage writes a redirect to url with the given status,
+// with a body rendered from the RedirectTemplate option. The
+// headers are expected to be set already.
+func (rd *redirector) redirectPage(w http.ResponseWriter, r *http.Request, url string, status int) {
+	var page bytes.Buffer
+	if err := rd.opts.RedirectTemplate.Execute(&page, RedirectPage{URL: url, Status: status}); err != nil {
+		rd.log(r, slog.LevelError, "Error while rendering redirect", slog.Any("error", err))
+		rd.error(w, r, http.StatusInternalServerError)
+		return
+	}
+	// With a Content-Type set, http.Redirect only writes the
+	// Location header and the status, leaving the body to us.
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(page.Len()))
+	http.Redirect(w, r, url, status)
+	w.Write(page.Bytes())
+}
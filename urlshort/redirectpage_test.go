@@ -0,0 +1,37 @@
+package urlshort
+
+import (
+	"html/template"
+	"net/http"
+	"testing"
+)
+
+func TestRedirectTemplate(t *testing.T) {
+	urls := ShortenedUrls{{Path: "/a", Url: "https://example.com/a?x=1&y=2"}}
+	tests := []struct {
+		name     string
+		tmpl     string
+		method   string
+		status   int
+		location string
+		body     string
+	}{
+		{"rendered", `<a href="{{.URL}}">{{.Status}}</a>`, http.MethodGet, http.StatusMovedPermanently, "https://example.com/a?x=1&y=2", `<a href="https://example.com/a?x=1&amp;y=2">301</a>`},
+		{"HEAD", `<a href="{{.URL}}">{{.Status}}</a>`, http.MethodHead, http.StatusMovedPermanently, "https://example.com/a?x=1&y=2", ""},
+		{"failing", `{{.Missing}}`, http.MethodGet, http.StatusInternalServerError, "", "Internal Server Error\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{RedirectTemplate: template.Must(template.New("page").Parse(tt.tmpl)), Logger: discardLogger}
+			handler, err := UrlsHandler(urls, opts, http.NotFoundHandler())
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc := redirectCase{method: tt.method, target: "/a", status: tt.status, location: tt.location}
+			checkRedirects(t, handler, []redirectCase{tc})
+			if got := serveCase(handler, tc).Body.String(); got != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type redisRedirector struct {
+	redirector
+	client    *redis.Client
+	keyPrefix string
+}
+
+// RedisHandler will return an http.Handler that looks up the
+// url for each request in Redis, under the key made of
+// keyPrefix followed by the request path. If the key does not
+// exist, then the fallback http.Handler will be called instead.
+//
+// Since every lookup goes to Redis, changes to the keys are
+// visible right away to all handlers sharing the same server.
+// If Redis cannot be reached, the error is logged and the
+// request is treated as a miss, rather than failing with a 500.
+func RedisHandler(client *redis.Client, keyPrefix string, fallback http.Handler) http.Handler {
+	opts, _ := Options{}.withDefaults()
+	return &redisRedirector{
+		redirector: redirector{opts: opts, fallback: fallback},
+		client:     client,
+		keyPrefix:  keyPrefix,
+	}
+}
+
+func (rr *redisRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rr.serve(w, r, rr.lookupIn(r.Context(), rr, "Redis"))
+}
+
+// Match implements Matcher.
+func (rr *redisRedirector) Match(r *http.Request) (string, bool) {
+	return rr.matchRequest(r, rr.lookupIn(r.Context(), rr, "Redis"))
+}
+
+// Lookup implements Store by getting the key of path.
+func (rr *redisRedirector) Lookup(ctx context.Context, path string) (string, bool, error) {
+	url, err := rr.client.Get(ctx, rr.keyPrefix+path).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return url, true, nil
+}
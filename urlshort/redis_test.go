@@ -0,0 +1,74 @@
+package urlshort
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisClient returns a client for the Redis server at
+// URLSHORT_TEST_REDIS_ADDR, skipping the test if it is not set.
+// Keys are written to database 15, which is flushed before and
+// after the test.
+func redisClient(t *testing.T) *redis.Client {
+	t.Helper()
+	addr := os.Getenv("URLSHORT_TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("URLSHORT_TEST_REDIS_ADDR not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr, DB: 15})
+	ctx := context.Background()
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.FlushDB(ctx)
+		client.Close()
+	})
+	return client
+}
+
+func TestRedisHandler(t *testing.T) {
+	client := redisClient(t)
+	ctx := context.Background()
+	if err := client.Set(ctx, "urls:/a", "https://example.com/a", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	handler := RedisHandler(client, "urls:", http.NotFoundHandler())
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "miss", target: "/b", status: http.StatusNotFound},
+	})
+
+	// Keys set later are visible right away.
+	if err := client.Set(ctx, "urls:/b", "https://example.com/b", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "added", target: "/b", status: http.StatusMovedPermanently, location: "https://example.com/b"},
+	})
+}
+
+func TestRedisHandlerUnreachable(t *testing.T) {
+	// Take a free port and close it, so that nothing listens there.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	handler := RedisHandler(client, "urls:", http.NotFoundHandler())
+	checkRedirects(t, handler, []redirectCase{
+		{name: "treated as a miss", target: "/a", status: http.StatusNotFound},
+	})
+	if _, _, err := handler.(Store).Lookup(context.Background(), "/a"); err == nil {
+		t.Error("Lookup() = nil error, want the connection error")
+	}
+}
@@ -0,0 +1,106 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RegexRule maps the request paths matching Pattern to a url
+// built from Template, in which $1, ${1} or ${name} stand for
+// what the groups of Pattern captured, as in
+// regexp.Regexp.Expand.
+type RegexRule struct {
+	Pattern  string `json:"pattern" yaml:"pattern" toml:"pattern"`
+	Template string `json:"template" yaml:"template" toml:"template"`
+}
+
+type compiledRule struct {
+	re       *regexp.Regexp
+	template string
+}
+
+type regexRedirector struct {
+	redirector
+	rules []compiledRule
+}
+
+// RegexHandler will return an http.Handler that redirects the
+// paths matching the pattern of one of the rules to the url
+// built from its template. The rules are tried in order, and
+// the first one whose pattern matches wins, so with the rule
+//
+//	{Pattern: `^/p/(\d+)$`, Template: "https://example.com/product/$1"}
+//
+// a request for /p/42 is redirected to
+// https://example.com/product/42. Patterns are not anchored
+// unless they say so with ^ and $. Captured text is escaped
+// before being substituted, except for slashes. If no rule
+// matches, then the fallback http.Handler will be called
+// instead.
+//
+// The only errors that can be returned are for patterns that
+// are not valid regular expressions, naming the rule.
+func RegexHandler(rules []RegexRule, fallback http.Handler) (http.Handler, error) {
+	opts, _ := Options{}.withDefaults()
+	rr := &regexRedirector{
+		redirector: redirector{opts: opts, fallback: fallback},
+		rules:      make([]compiledRule, len(rules)),
+	}
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: invalid pattern '%s': %w", i, rule.Pattern, err)
+		}
+		rr.rules[i] = compiledRule{re: re, template: rule.Template}
+	}
+	return rr, nil
+}
+
+// expand returns the url for path if it matches the rule.
+func (cr compiledRule) expand(path string) (string, bool) {
+	match := cr.re.FindStringSubmatchIndex(path)
+	if match == nil {
+		return "", false
+	}
+	// Expand reads the captures out of its source, so give it the
+	// escaped captures one after the other, with their new bounds.
+	var escaped strings.Builder
+	bounds := make([]int, len(match))
+	for i := 0; i < len(match); i += 2 {
+		if match[i] < 0 {
+			bounds[i], bounds[i+1] = -1, -1
+			continue
+		}
+		bounds[i] = escaped.Len()
+		escaped.WriteString(escapeCapture(path[match[i]:match[i+1]]))
+		bounds[i+1] = escaped.Len()
+	}
+	return string(cr.re.ExpandString(nil, cr.template, escaped.String(), bounds)), true
+}
+
+// escapeCapture escapes captured path text for use in a url,
+// keeping its slashes.
+func escapeCapture(capture string) string {
+	return strings.ReplaceAll(url.PathEscape(capture), "%2F", "/")
+}
+
+func (rr *regexRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rr.serve(w, r, rr.lookup)
+}
+
+// Match implements Matcher.
+func (rr *regexRedirector) Match(r *http.Request) (string, bool) {
+	return rr.matchRequest(r, rr.lookup)
+}
+
+func (rr *regexRedirector) lookup(key string) (ShortenedUrl, bool) {
+	for _, rule := range rr.rules {
+		if url, matched := rule.expand(key); matched {
+			return ShortenedUrl{Path: rule.re.String(), Url: url}, true
+		}
+	}
+	return ShortenedUrl{}, false
+}
@@ -0,0 +1,39 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRegexHandler(t *testing.T) {
+	handler, err := RegexHandler([]RegexRule{
+		{Pattern: `^/p/(\d+)$`, Template: "https://example.com/product/$1"},
+		{Pattern: `^/u/(?P<user>[^/]+)/(?P<rest>.*)$`, Template: "https://example.com/users/${user}/${rest}"},
+		{Pattern: `^/p/`, Template: "https://example.com/products"},
+		{Pattern: `docs`, Template: "https://example.com/docs"},
+		{Pattern: `^/opt(/(\w+))?$`, Template: "https://example.com/opt/$2"},
+	}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "numbered group", target: "/p/42", status: http.StatusMovedPermanently, location: "https://example.com/product/42"},
+		{name: "first rule wins", target: "/p/abc", status: http.StatusMovedPermanently, location: "https://example.com/products"},
+		{name: "named groups keep slashes", target: "/u/ann/a/b", status: http.StatusMovedPermanently, location: "https://example.com/users/ann/a/b"},
+		{name: "captures escaped", target: "/u/ann/a%20b%3Fc", status: http.StatusMovedPermanently, location: "https://example.com/users/ann/a%20b%3Fc"},
+		{name: "unanchored", target: "/old/docs/page", status: http.StatusMovedPermanently, location: "https://example.com/docs"},
+		{name: "unmatched group", target: "/opt", status: http.StatusMovedPermanently, location: "https://example.com/opt/"},
+		{name: "miss", target: "/q/1", status: http.StatusNotFound},
+	})
+}
+
+func TestRegexHandlerInvalidPattern(t *testing.T) {
+	_, err := RegexHandler([]RegexRule{
+		{Pattern: `^/ok$`, Template: "https://example.com"},
+		{Pattern: `^/(unclosed$`, Template: "https://example.com"},
+	}, http.NotFoundHandler())
+	if err == nil || !strings.Contains(err.Error(), "rule 1") {
+		t.Errorf("RegexHandler() = %v, want an error naming rule 1", err)
+	}
+}
@@ -0,0 +1,140 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// ReloadableHandler is an http.Handler whose mappings can be
+// replaced while it serves requests, whatever format they are
+// read from, and which can be paused, for example during
+// maintenance. It is safe for concurrent use.
+type ReloadableHandler interface {
+	http.Handler
+
+	// Reload parses input and replaces the mappings being served
+	// with it, all at once. If input cannot be parsed or built,
+	// the error is returned and the previous mappings keep being
+	// served.
+	Reload(input []byte) error
+
+	// Pause makes every request go to handler, or to the fallback
+	// if handler is nil, without looking at the mappings, until
+	// Resume is called.
+	Pause(handler http.Handler)
+
+	// Resume makes requests be served from the mappings again.
+	Resume()
+
+	// Paused reports whether the handler is paused.
+	Paused() bool
+
+	// Snapshot returns a copy of the mappings being served, one
+	// entry per path sorted by it. Pass it to Restore, here or on
+	// another handler, or to ExportUrlsYAML or ExportUrlsJSON to
+	// save it.
+	Snapshot() ShortenedUrls
+
+	// Restore replaces the mappings being served with urls, such
+	// as a Snapshot, all at once, as Reload does with parsed
+	// input. If urls cannot be built, the error is returned and
+	// the previous mappings keep being served.
+	Restore(urls ShortenedUrls) error
+
+	// ReloadStats returns how the calls to Reload and Restore
+	// went.
+	ReloadStats() ReloadStats
+}
+
+type reloadableRedirector struct {
+	redirector
+	pauser
+	reloadRecorder
+	parse   func([]byte) (ShortenedUrls, error)
+	entries atomic.Pointer[map[string]ShortenedUrl]
+}
+
+// NewReloadableHandler parses input in the given format and
+// returns a ReloadableHandler serving its mappings. Later calls
+// to Reload expect the same format. The format is one of those
+// accepted by ValidateConfig. If a path is not mapped, then the
+// fallback http.Handler will be called instead.
+func NewReloadableHandler(input []byte, format string, fallback http.Handler) (ReloadableHandler, error) {
+	parse, err := parserForFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	opts, _ := Options{}.withDefaults()
+	rr := &reloadableRedirector{
+		redirector: redirector{opts: opts, fallback: fallback},
+		parse:      parse,
+	}
+	if err := rr.Reload(input); err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
+
+// NewReloadableYAMLHandler works like YAMLHandler, but returns a
+// ReloadableHandler that can later be given new YAML.
+func NewReloadableYAMLHandler(yml []byte, fallback http.Handler) (ReloadableHandler, error) {
+	return NewReloadableHandler(yml, "yaml", fallback)
+}
+
+// NewReloadableJSONHandler works like JSONHandler, but returns a
+// ReloadableHandler that can later be given new JSON.
+func NewReloadableJSONHandler(jsonInput []byte, fallback http.Handler) (ReloadableHandler, error) {
+	return NewReloadableHandler(jsonInput, "json", fallback)
+}
+
+func (rr *reloadableRedirector) Reload(input []byte) error {
+	return rr.recordReload(rr.load(input))
+}
+
+func (rr *reloadableRedirector) Snapshot() ShortenedUrls {
+	return snapshotEntries(*rr.entries.Load())
+}
+
+func (rr *reloadableRedirector) Restore(urls ShortenedUrls) error {
+	return rr.recordReload(rr.store(urls))
+}
+
+func (rr *reloadableRedirector) load(input []byte) error {
+	urls, err := rr.parse(input)
+	if err != nil {
+		return err
+	}
+	return rr.store(urls)
+}
+
+// store builds urls and replaces the mappings being served
+// with them.
+func (rr *reloadableRedirector) store(urls ShortenedUrls) error {
+	entries, err := rr.opts.buildEntries(urls)
+	if err != nil {
+		return err
+	}
+	rr.entries.Store(&entries)
+	return nil
+}
+
+func (rr *reloadableRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if rr.servePaused(w, r, rr.fallback) {
+		return
+	}
+	rr.serve(w, r, rr.lookup)
+}
+
+// Match implements Matcher. While paused, every request is
+// reported as a miss.
+func (rr *reloadableRedirector) Match(r *http.Request) (string, bool) {
+	if rr.Paused() {
+		return "", false
+	}
+	return rr.matchRequest(r, rr.lookup)
+}
+
+func (rr *reloadableRedirector) lookup(key string) (ShortenedUrl, bool) {
+	entry, exists := (*rr.entries.Load())[key]
+	return entry, exists
+}
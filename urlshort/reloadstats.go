@@ -0,0 +1,58 @@
+package urlshort
+
+import (
+	"sync"
+	"time"
+)
+
+// ReloadStats tells how the reloads of a handler went, so that
+// an alert can fire when the handler keeps serving stale
+// mappings because new ones fail to load. The load done when
+// the handler is built counts as a reload.
+type ReloadStats struct {
+	// Successes is the number of reloads that replaced the
+	// mappings.
+	Successes uint64
+	// Failures is the number of reloads that failed, leaving the
+	// previous mappings in place.
+	Failures uint64
+	// LastSuccess is when the mappings were last replaced.
+	LastSuccess time.Time
+	// LastFailure is when a reload last failed, or the zero time
+	// if none ever did.
+	LastFailure time.Time
+	// LastError is the error of the last failed reload. It is
+	// kept after later reloads succeed, so compare LastFailure
+	// with LastSuccess to tell whether the handler is failing.
+	LastError error
+}
+
+// reloadRecorder keeps the ReloadStats of a handler.
+type reloadRecorder struct {
+	mu    sync.Mutex
+	stats ReloadStats
+}
+
+// ReloadStats returns a snapshot of the reload statistics.
+func (rr *reloadRecorder) ReloadStats() ReloadStats {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return rr.stats
+}
+
+// recordReload records a reload attempt that failed with err,
+// or succeeded if err is nil, and returns err.
+func (rr *reloadRecorder) recordReload(err error) error {
+	now := time.Now()
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	if err != nil {
+		rr.stats.Failures++
+		rr.stats.LastFailure = now
+		rr.stats.LastError = err
+	} else {
+		rr.stats.Successes++
+		rr.stats.LastSuccess = now
+	}
+	return err
+}
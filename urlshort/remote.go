@@ -0,0 +1,179 @@
+package urlshort
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPConfigHandler fetches the config at configURL with a GET
+// request, parses it in the given format, and returns a handler
+// serving its mappings. The format is one of those accepted by
+// ValidateConfig. If a path is not mapped, then the fallback
+// http.Handler will be called instead.
+//
+// The context bounds the fetch, so give it a deadline to avoid
+// waiting on a slow config service forever. Errors are returned
+// for failed requests, responses with a status other than 200,
+// and config that cannot be parsed.
+//
+// The config is fetched once, and not tried again on failure:
+// use HTTPConfigHandlerWithRetry for that, or
+// NewRemoteConfigHandler to fetch it again periodically.
+func HTTPConfigHandler(ctx context.Context, configURL string, format string, fallback http.Handler) (http.Handler, error) {
+	urls, err := fetchConfig(ctx, configURL, format)
+	if err != nil {
+		return nil, err
+	}
+	return UrlsHandler(urls, Options{}, fallback)
+}
+
+// RemoteConfigHandler is an http.Handler serving mappings
+// fetched over HTTP, which fetches them again at a fixed
+// interval. ReloadStats tells how the fetches went. It is safe
+// for concurrent use.
+type RemoteConfigHandler struct {
+	redirector
+	reloadRecorder
+	configURL string
+	format    string
+	entries   atomic.Pointer[map[string]ShortenedUrl]
+	stop      context.CancelFunc
+	done      sync.WaitGroup
+}
+
+// NewRemoteConfigHandler works like HTTPConfigHandler, but
+// fetches the config again every interval. When a refetch
+// fails, the error is logged and the previous mappings keep
+// being served. The context only bounds the first fetch.
+// An error is returned if interval is not positive; use
+// HTTPConfigHandler to fetch the config only once.
+//
+// Call Close to stop refetching.
+func NewRemoteConfigHandler(ctx context.Context, configURL string, format string, interval time.Duration, fallback http.Handler) (*RemoteConfigHandler, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid refetch interval %s: must be positive", interval)
+	}
+	opts, _ := Options{}.withDefaults()
+	rh := &RemoteConfigHandler{
+		redirector: redirector{opts: opts, fallback: fallback},
+		configURL:  configURL,
+		format:     format,
+	}
+	if err := rh.reload(ctx); err != nil {
+		return nil, err
+	}
+
+	pollCtx, stop := context.WithCancel(context.Background())
+	rh.stop = stop
+	rh.done.Add(1)
+	go rh.poll(pollCtx, interval)
+	return rh, nil
+}
+
+func (rh *RemoteConfigHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rh.serve(w, r, rh.lookup)
+}
+
+// Match implements Matcher.
+func (rh *RemoteConfigHandler) Match(r *http.Request) (string, bool) {
+	return rh.matchRequest(r, rh.lookup)
+}
+
+// Close stops refetching the config, aborting a fetch in
+// progress, implementing io.Closer. It returns once the
+// background goroutine has exited and its ticker is stopped,
+// and it can be called more than once. The handler keeps
+// serving the mappings it had fetched last.
+func (rh *RemoteConfigHandler) Close() error {
+	rh.stop()
+	rh.done.Wait()
+	return nil
+}
+
+func (rh *RemoteConfigHandler) lookup(key string) (ShortenedUrl, bool) {
+	entry, exists := (*rh.entries.Load())[key]
+	return entry, exists
+}
+
+func (rh *RemoteConfigHandler) poll(ctx context.Context, interval time.Duration) {
+	defer rh.done.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := rh.reload(ctx); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				rh.opts.logger().Error("Error while refetching config, keeping previous urls",
+					slog.String("config", rh.configURL), slog.Any("error", err))
+				continue
+			}
+			rh.opts.logger().Info("Refetched urls from config", slog.String("config", rh.configURL))
+		}
+	}
+}
+
+func (rh *RemoteConfigHandler) reload(ctx context.Context) error {
+	return rh.recordReload(rh.load(ctx))
+}
+
+func (rh *RemoteConfigHandler) load(ctx context.Context) error {
+	urls, err := fetchConfig(ctx, rh.configURL, rh.format)
+	if err != nil {
+		return err
+	}
+	entries, err := rh.opts.buildEntries(urls)
+	if err != nil {
+		return err
+	}
+	rh.entries.Store(&entries)
+	return nil
+}
+
+func fetchConfig(ctx context.Context, configURL string, format string) (ShortenedUrls, error) {
+	parse, err := parserForFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	input, _, err := fetchInput(ctx, configURL)
+	if err != nil {
+		return nil, err
+	}
+	return parse(input)
+}
+
+// fetchInput gets the body at configURL, and reports along with
+// any error whether trying again might succeed: after a network
+// error or a response with a 5xx, 408 or 429 status, but not
+// after any other status or once ctx is done.
+func fetchInput(ctx context.Context, configURL string) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, configURL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout ||
+			resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("fetching config '%s': unexpected status %s", configURL, resp.Status)
+	}
+	input, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, ctx.Err() == nil, fmt.Errorf("fetching config '%s': %w", configURL, err)
+	}
+	return input, false, nil
+}
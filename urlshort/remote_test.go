@@ -0,0 +1,18 @@
+package urlshort
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewRemoteConfigHandlerRejectsInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		rh, err := NewRemoteConfigHandler(context.Background(), "http://127.0.0.1:0", "json", interval, http.NotFoundHandler())
+		if err == nil {
+			rh.Close()
+			t.Errorf("interval %s: got no error", interval)
+		}
+	}
+}
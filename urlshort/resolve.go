@@ -0,0 +1,51 @@
+package urlshort
+
+import "net/http"
+
+// ResolveResult is where a handler would send a request for
+// Path.
+type ResolveResult struct {
+	// Path is the path as given, which may include a query.
+	Path string `json:"path"`
+	// Matched is true if the request would be redirected rather
+	// than passed to the fallback.
+	Matched bool `json:"matched"`
+	// URL is where the request would be redirected to. It is
+	// empty for misses and for entries that are gone.
+	URL string `json:"url,omitempty"`
+}
+
+// Resolve reports, for each of paths, where the handler
+// UrlsHandler builds from urls with the default options would
+// redirect a GET request for it, without serving anything. It
+// is meant for checking a config against paths taken from
+// production logs before switching over. The error is the one
+// UrlsHandler would return.
+//
+// Use ResolveMatcher to check a handler built with other
+// options, such as prefix matching, or of another kind, such as
+// from RegexHandler.
+func Resolve(urls ShortenedUrls, paths []string) ([]ResolveResult, error) {
+	handler, err := newMapHandler(urls, Options{}, http.NotFoundHandler())
+	if err != nil {
+		return nil, err
+	}
+	return ResolveMatcher(handler, paths), nil
+}
+
+// ResolveMatcher reports, for each of paths, where m would
+// redirect a GET request for it, using its Match method. A path
+// may include a query, which is passed to m. Paths that are not
+// valid request targets are reported as misses.
+func ResolveMatcher(m Matcher, paths []string) []ResolveResult {
+	results := make([]ResolveResult, len(paths))
+	for i, path := range paths {
+		results[i] = ResolveResult{Path: path}
+		r, ok := lookupRequest(path)
+		if !ok {
+			continue
+		}
+		results[i].URL, results[i].Matched = m.Match(r)
+	}
+	return results
+}
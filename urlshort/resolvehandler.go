@@ -0,0 +1,53 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// resolvedLink and unresolvedLink are the JSON bodies of the
+// responses of ResolveHandler to a match and to a miss. Their
+// fields, and their names, must not change.
+type resolvedLink struct {
+	Path   string `json:"path"`
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+}
+
+type unresolvedLink struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// ResolveHandler returns an http.Handler that tells where
+// looker, such as any handler of this package, would send each
+// request, instead of sending it there, so that a client can
+// resolve a short link itself before deciding to navigate. The
+// request is looked up as it is, host and headers included,
+// with LookupRequest when looker implements RequestLooker, as
+// every handler of this package does, or else by its path and
+// query with LookupPath. A match gets a 200 with a JSON body
+// such as:
+//
+//	{"path":"/github","url":"https://github.com","status":301}
+//
+// The status is the one the redirect would have, 410 for an
+// entry that is gone, whose url is empty, 200 for an
+// interstitial page and 0 for a proxied entry, as LookupPath
+// reports them. A miss gets a 404 with the path and an error:
+//
+//	{"path":"/nope","error":"not found"}
+func ResolveHandler(looker PathLooker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body any = unresolvedLink{Path: r.URL.Path, Error: "not found"}
+		status := http.StatusNotFound
+		if url, matchStatus, matched, _ := lookupAny(looker, r); matched {
+			body = resolvedLink{Path: r.URL.Path, URL: url, Status: matchStatus}
+			status = http.StatusOK
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	})
+}
@@ -0,0 +1,71 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolveHandler(t *testing.T) {
+	hosts, err := HostHandler(map[string]map[string]string{
+		"go.example": {"/x": "https://x.example.com"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, err := QueryHandler(ShortenedUrls{
+		{Path: "/t", MatchHeaders: map[string]string{"X-Tenant": "a"}, Url: "https://a.example.com"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, err := UrlsHandler(ShortenedUrls{
+		{Path: "/github", Url: "https://github.com"},
+		{Path: "/old", Gone: true},
+	}, Options{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		looker PathLooker
+		target string
+		header http.Header
+		status int
+		body   string
+	}{
+		{"match", paths.(PathLooker), "/github", nil, http.StatusOK, `{"path":"/github","url":"https://github.com","status":301}`},
+		{"gone", paths.(PathLooker), "/old", nil, http.StatusOK, `{"path":"/old","url":"","status":410}`},
+		{"miss", paths.(PathLooker), "/nope", nil, http.StatusNotFound, `{"path":"/nope","error":"not found"}`},
+		{"host", hosts.(PathLooker), "http://go.example/x", nil, http.StatusOK, `{"path":"/x","url":"https://x.example.com","status":301}`},
+		{"other host", hosts.(PathLooker), "http://other.example/x", nil, http.StatusNotFound, `{"path":"/x","error":"not found"}`},
+		{"header", query.(PathLooker), "/t", http.Header{"X-Tenant": {"a"}}, http.StatusOK, `{"path":"/t","url":"https://a.example.com","status":301}`},
+		{"no header", query.(PathLooker), "/t", nil, http.StatusNotFound, `{"path":"/t","error":"not found"}`},
+		{"path looker only", pathOnly{paths.(PathLooker)}, "/github", nil, http.StatusOK, `{"path":"/github","url":"https://github.com","status":301}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for name, values := range tt.header {
+				r.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			ResolveHandler(tt.looker).ServeHTTP(rec, r)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Body.String(); got != tt.body+"\n" {
+				t.Errorf("body = %s, want %s", got, tt.body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q", got)
+			}
+		})
+	}
+}
+
+// pathOnly hides every method of a PathLooker but LookupPath.
+type pathOnly struct{ looker PathLooker }
+
+func (p pathOnly) LookupPath(path string) (string, int, bool) { return p.looker.LookupPath(path) }
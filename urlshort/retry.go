@@ -0,0 +1,103 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Default values of the zero fields of a Retry.
+const (
+	defaultRetryAttempts = 3
+	defaultRetryDelay    = 500 * time.Millisecond
+	defaultRetryMaxDelay = time.Minute
+)
+
+// Retry tells how often to try fetching a remote config before
+// giving up, waiting twice as long after each failed attempt.
+type Retry struct {
+	// MaxAttempts is how many times the config is fetched at
+	// most, the first time included. When zero, it is three.
+	MaxAttempts int
+	// BaseDelay is how long to wait after the first failed
+	// attempt. When zero, it is half a second.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between two attempts. When zero, it
+	// is a minute.
+	MaxDelay time.Duration
+}
+
+// delay returns how long to wait after the given failed
+// attempt, counted from 1.
+func (rt Retry) delay(attempt int) time.Duration {
+	delay := rt.BaseDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	maxDelay := rt.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+	// Stop doubling at the cap, before delay can overflow.
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		if delay > maxDelay/2 {
+			return maxDelay
+		}
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// HTTPConfigHandlerWithRetry works like HTTPConfigHandler, but
+// tries fetching the config again as retry asks when the config
+// service seems briefly unavailable: after a network error or
+// a response with a 5xx, 408 or 429 status. Each retry is logged
+// as a warning. Other statuses, and config that cannot be
+// parsed, fail at once.
+//
+// Once every attempt has failed, the error of the last one is
+// returned. When the context is done while waiting to try
+// again, the error returned wraps both the last error and the
+// one of the context.
+func HTTPConfigHandlerWithRetry(ctx context.Context, configURL string, format string, retry Retry, fallback http.Handler) (http.Handler, error) {
+	urls, err := fetchConfigRetry(ctx, configURL, format, retry)
+	if err != nil {
+		return nil, err
+	}
+	return UrlsHandler(urls, Options{}, fallback)
+}
+
+func fetchConfigRetry(ctx context.Context, configURL string, format string, retry Retry) (ShortenedUrls, error) {
+	parse, err := parserForFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	attempts := retry.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultRetryAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		input, again, err := fetchInput(ctx, configURL)
+		if err == nil {
+			return parse(input)
+		}
+		if !again || attempt >= attempts {
+			return nil, err
+		}
+		delay := retry.delay(attempt)
+		slog.Warn("Error while fetching config, retrying",
+			slog.String("config", configURL),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.Any("error", err))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package urlshort
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   Retry
+		attempt int
+		want    time.Duration
+	}{
+		{"default first", Retry{}, 1, defaultRetryDelay},
+		{"default doubles", Retry{}, 3, 4 * defaultRetryDelay},
+		{"default cap", Retry{}, 1000, defaultRetryMaxDelay},
+		{"base", Retry{BaseDelay: time.Second}, 2, 2 * time.Second},
+		{"max", Retry{BaseDelay: time.Second, MaxDelay: 3 * time.Second}, 5, 3 * time.Second},
+		{"huge base", Retry{BaseDelay: math.MaxInt64 / 2, MaxDelay: math.MaxInt64}, 100, math.MaxInt64},
+		{"no overflow", Retry{BaseDelay: time.Hour, MaxDelay: math.MaxInt64}, math.MaxInt32, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.retry.delay(tt.attempt); got != tt.want {
+				t.Errorf("delay(%d) = %s, want %s", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package urlshort
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+// rewrite returns the url the Rewrite option turns destination
+// into for r, or destination itself if there is no such option
+// or the result is invalid.
+func (rd *redirector) rewrite(r *http.Request, destination string) string {
+	if rd.opts.Rewrite == nil {
+		return destination
+	}
+	rewritten := rd.opts.Rewrite(r, destination)
+	if rewritten == destination {
+		return destination
+	}
+	var err error
+	switch {
+	case rewritten == "":
+		err = errors.New("empty url")
+	case validateUrl(destination) == nil:
+		err = validateUrl(rewritten)
+	default:
+		_, err = url.Parse(rewritten)
+	}
+	if err != nil {
+		rd.log(r, slog.LevelWarn, "Invalid rewritten url, keeping original",
+			slog.String("url", destination),
+			slog.String("rewritten", rewritten),
+			slog.Any("error", err))
+		return destination
+	}
+	return rewritten
+}
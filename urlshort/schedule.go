@@ -0,0 +1,32 @@
+package urlshort
+
+import "time"
+
+// ScheduleState tells whether an entry redirects at a given
+// time, according to its NotBefore and ExpiresAt.
+type ScheduleState string
+
+const (
+	// ScheduleActive is the state of an entry which redirects.
+	ScheduleActive ScheduleState = "active"
+
+	// ScheduleScheduled is the state of an entry whose NotBefore
+	// has not come yet.
+	ScheduleScheduled ScheduleState = "scheduled"
+
+	// ScheduleExpired is the state of an entry whose ExpiresAt
+	// has passed.
+	ScheduleExpired ScheduleState = "expired"
+)
+
+// StateAt returns the schedule state of the entry at now. It
+// does not look at Enabled, which is separate from scheduling.
+func (u ShortenedUrl) StateAt(now time.Time) ScheduleState {
+	if u.NotBefore != nil && now.Before(*u.NotBefore) {
+		return ScheduleScheduled
+	}
+	if u.ExpiresAt != nil && !now.Before(*u.ExpiresAt) {
+		return ScheduleExpired
+	}
+	return ScheduleActive
+}
@@ -0,0 +1,108 @@
+package urlshort
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// JSONSchema is the JSON Schema that the input of
+// JSONSchemaHandler is validated against. It describes the
+// format JSONHandler reads, without its leniencies: unknown
+// fields, which JSONHandler ignores, are violations.
+//
+//go:embed schema.json
+var JSONSchema []byte
+
+var compiledSchema = func() *jsonschema.Schema {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+	if err := compiler.AddResource("schema.json", bytes.NewReader(JSONSchema)); err != nil {
+		panic(err)
+	}
+	return compiler.MustCompile("schema.json")
+}()
+
+// SchemaError is returned when JSON input does not match
+// JSONSchema. It carries every violation found.
+type SchemaError struct {
+	Violations []SchemaViolation
+}
+
+// SchemaViolation is a single place where JSON input does not
+// match JSONSchema.
+type SchemaViolation struct {
+	// Location is the JSON Pointer to the offending value, such
+	// as /2/url, or the empty string for the whole document.
+	Location string
+	// Message describes the violation.
+	Message string
+}
+
+// Error returns one line per violation.
+func (e *SchemaError) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		lines[i] = violation.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (v SchemaViolation) String() string {
+	return fmt.Sprintf("at '%s': %s", v.Location, v.Message)
+}
+
+// JSONSchemaHandler works like JSONHandler, but first validates
+// jsonInput against JSONSchema. If it does not match, the error
+// is a *SchemaError listing where and how, which tells more
+// than the error of a failed decode, and catches mistakes the
+// decode lets through, such as a misspelled field.
+func JSONSchemaHandler(jsonInput []byte, fallback http.Handler) (http.Handler, error) {
+	if err := ValidateJSONSchema(jsonInput); err != nil {
+		return nil, err
+	}
+	return JSONHandler(jsonInput, fallback)
+}
+
+// ValidateJSONSchema validates jsonInput against JSONSchema. If
+// it does not match, the error is a *SchemaError. If it is not
+// JSON at all, the error is the one of the decode.
+func ValidateJSONSchema(jsonInput []byte) error {
+	jsonInput, err := prepareInput(jsonInput)
+	if err != nil {
+		return err
+	}
+	var document any
+	decoder := json.NewDecoder(bytes.NewReader(jsonInput))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		slog.Error("Error: " + err.Error())
+		return err
+	}
+	err = compiledSchema.Validate(document)
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+	schemaErr := &SchemaError{}
+	for _, basic := range validationErr.BasicOutput().Errors {
+		// The first error only says that the document is invalid,
+		// and those of the items holding a violation are empty.
+		if basic.KeywordLocation == "" || basic.Error == "" {
+			continue
+		}
+		schemaErr.Violations = append(schemaErr.Violations, SchemaViolation{
+			Location: basic.InstanceLocation,
+			Message:  basic.Error,
+		})
+	}
+	slog.Error("Error: " + schemaErr.Error())
+	return schemaErr
+}
@@ -0,0 +1,61 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestJSONSchemaHandler(t *testing.T) {
+	handler, err := JSONSchemaHandler([]byte(`[
+		{"path": "/a", "url": "https://example.com/a"},
+		{"path": "/old", "paths": ["/older"], "url": "https://example.com/old", "status": 302},
+		{"path": "/gone", "gone": true}
+	]`), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "status", target: "/old", status: http.StatusFound, location: "https://example.com/old"},
+		{name: "alias", target: "/older", status: http.StatusFound, location: "https://example.com/old"},
+		{name: "gone", target: "/gone", status: http.StatusGone},
+		{name: "miss", target: "/b", status: http.StatusNotFound},
+	})
+}
+
+func TestJSONSchemaHandlerErrors(t *testing.T) {
+	_, err := JSONSchemaHandler([]byte(`[
+		{"path": "/a", "url": "https://example.com/a"},
+		{"path": "/b", "link": "https://example.com/b"}
+	]`), http.NotFoundHandler())
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("JSONSchemaHandler(unknown field) = %v, want a *SchemaError", err)
+	}
+	found := false
+	for _, violation := range schemaErr.Violations {
+		if violation.Location == "/1" || violation.Location == "/1/link" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("violations = %+v, want one at /1", schemaErr.Violations)
+	}
+
+	_, err = JSONSchemaHandler([]byte(`[{"path": "/a", "url": `), http.NotFoundHandler())
+	if err == nil || errors.As(err, &schemaErr) {
+		t.Errorf("JSONSchemaHandler(truncated) = %v, want the decode error", err)
+	}
+}
+
+func TestJSONSchemaMatchesJSONHandler(t *testing.T) {
+	// Every valid fixture of JSONHandler must pass the schema too.
+	input := []byte(`[{"path": "/a", "url": "https://example.com/a", "methods": ["GET"], "max_hits": 2, "enabled": true}]`)
+	if err := ValidateJSONSchema(input); err != nil {
+		t.Errorf("ValidateJSONSchema() = %v", err)
+	}
+	if _, err := JSONHandler(input, http.NotFoundHandler()); err != nil {
+		t.Errorf("JSONHandler() = %v", err)
+	}
+}
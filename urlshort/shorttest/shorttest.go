@@ -0,0 +1,34 @@
+// Package shorttest provides helpers for testing the handlers
+// of package urlshort, and the configs they are built from,
+// without writing the httptest boilerplate by hand.
+package shorttest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// AssertRedirect sends a GET request for path to handler and
+// fails t unless the response has status wantStatus and a
+// Location header of wantURL:
+//
+//	handler, err := urlshort.YAMLHandler(config, http.NotFoundHandler())
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	shorttest.AssertRedirect(t, handler, "/urlshort", "https://github.com/gophercises/urlshort", http.StatusMovedPermanently)
+//
+// The request is served in memory, with httptest.NewRecorder.
+func AssertRedirect(t testing.TB, handler http.Handler, path, wantURL string, wantStatus int) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	if w.Code != wantStatus {
+		t.Errorf("GET %s: got status %d, want %d", path, w.Code, wantStatus)
+	}
+	if location := w.Header().Get("Location"); location != wantURL {
+		t.Errorf("GET %s: got Location '%s', want '%s'", path, location, wantURL)
+	}
+}
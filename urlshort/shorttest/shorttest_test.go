@@ -0,0 +1,71 @@
+package shorttest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// fakeTB records the failures reported to it instead of
+// failing the test running it.
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertRedirect(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/urlshort" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, "https://github.com/gophercises/urlshort", http.StatusMovedPermanently)
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantURL    string
+		wantStatus int
+		wantErrors []string
+	}{
+		{
+			name:       "pass",
+			path:       "/urlshort",
+			wantURL:    "https://github.com/gophercises/urlshort",
+			wantStatus: http.StatusMovedPermanently,
+		},
+		{
+			name:       "wrong status",
+			path:       "/urlshort",
+			wantURL:    "https://github.com/gophercises/urlshort",
+			wantStatus: http.StatusFound,
+			wantErrors: []string{"GET /urlshort: got status 301, want 302"},
+		},
+		{
+			name:       "not mapped",
+			path:       "/other",
+			wantURL:    "https://github.com/gophercises/urlshort",
+			wantStatus: http.StatusMovedPermanently,
+			wantErrors: []string{
+				"GET /other: got status 404, want 301",
+				"GET /other: got Location '', want 'https://github.com/gophercises/urlshort'",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &fakeTB{}
+			AssertRedirect(tb, handler, tt.path, tt.wantURL, tt.wantStatus)
+			if fmt.Sprint(tb.errors) != fmt.Sprint(tt.wantErrors) {
+				t.Errorf("got errors %q, want %q", tb.errors, tt.wantErrors)
+			}
+		})
+	}
+}
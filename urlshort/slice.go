@@ -0,0 +1,92 @@
+package urlshort
+
+import (
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+type sliceRedirector struct {
+	redirector
+	keys []string
+	urls ShortenedUrls
+}
+
+// SliceHandler will return an http.Handler that will attempt
+// to map any paths to their corresponding URL, like MapHandler,
+// but keeps urls as a slice and scans it in order for each
+// request. Aliases in Paths are tried right after the Path of
+// their entry. When a path is listed more than once, the first
+// entry wins, instead of being reported as a conflict. If the
+// path is not found, then the fallback http.Handler will be
+// called instead.
+//
+// A lookup takes time proportional to the number of entries,
+// rather than the constant time of MapHandler, but skips the
+// hashing and the memory of the map. It suits small sets, up to
+// about 20 entries, or configs where the order of the entries
+// matters. For anything larger, use MapHandler or UrlsHandler.
+//
+// The slice is copied, so later changes to urls are not seen.
+// Entries are checked as UrlsHandler checks each one, but since
+// SliceHandler returns no error, an invalid entry, such as one
+// with an unsupported Status, is logged and left out.
+func SliceHandler(urls ShortenedUrls, fallback http.Handler) http.Handler {
+	opts, _ := Options{}.withDefaults()
+	sr := &sliceRedirector{
+		redirector: redirector{opts: opts, fallback: fallback},
+	}
+	for _, entry := range validEntries(urls) {
+		if entry.MaxHits > 0 {
+			entry.hits = new(atomic.Int64)
+		}
+		for _, path := range entryPaths(entry) {
+			entry := entry
+			entry.Path = path
+			sr.keys = append(sr.keys, opts.entryKey(path))
+			sr.urls = append(sr.urls, entry)
+		}
+	}
+	return sr
+}
+
+// validEntries returns urls, in order, without the entries
+// failing the checks buildEntries runs on each entry on its
+// own, logging why each of those is left out.
+func validEntries(urls ShortenedUrls) ShortenedUrls {
+	configErr := &ConfigError{}
+	checkTargets(configErr, urls)
+	checkStatuses(configErr, urls)
+	checkMaxHits(configErr, urls)
+	invalid := make(map[int]bool, len(configErr.Problems))
+	for _, problem := range configErr.Problems {
+		slog.Error("Skipping invalid entry", slog.Int("index", problem.Index),
+			slog.String("path", problem.Path), slog.String("problem", problem.Message))
+		invalid[problem.Index] = true
+	}
+	valid := make(ShortenedUrls, 0, len(urls))
+	for i, entry := range urls {
+		if !invalid[i] {
+			valid = append(valid, entry)
+		}
+	}
+	return valid
+}
+
+func (sr *sliceRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sr.serve(w, r, sr.lookup)
+}
+
+// Match implements Matcher.
+func (sr *sliceRedirector) Match(r *http.Request) (string, bool) {
+	return sr.matchRequest(r, sr.lookup)
+}
+
+func (sr *sliceRedirector) lookup(key string) (ShortenedUrl, bool) {
+	for i, entryKey := range sr.keys {
+		if entryKey == key {
+			return sr.urls[i], true
+		}
+	}
+	return ShortenedUrl{}, false
+}
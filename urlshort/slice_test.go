@@ -0,0 +1,33 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSliceHandler(t *testing.T) {
+	handler := SliceHandler(ShortenedUrls{
+		{Path: "/a", Url: "https://example.com/first"},
+		{Path: "/a", Url: "https://example.com/second"},
+		{Path: "/bad", Url: "https://example.com/bad", Status: 42},
+		{Path: "/weights", Targets: []Target{{Url: "https://example.com/w", Weight: -1}}},
+		{Path: "/once", Url: "https://example.com/once", MaxHits: 1},
+		{Path: "/found", Url: "https://example.com/found", Status: http.StatusFound},
+		{Path: "/old", Gone: true},
+		{Path: "/gh", Paths: []string{"/github"}, Url: "https://github.com"},
+		{Paths: []string{"/docs", "/a"}, Url: "https://example.com/docs"},
+	}, http.NotFoundHandler())
+	checkRedirects(t, handler, []redirectCase{
+		{name: "first wins", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/first"},
+		{name: "invalid status skipped", target: "/bad", status: http.StatusNotFound},
+		{name: "invalid weight skipped", target: "/weights", status: http.StatusNotFound},
+		{name: "max hits", target: "/once", status: http.StatusMovedPermanently, location: "https://example.com/once"},
+		{name: "max hits used up", target: "/once", status: http.StatusGone},
+		{name: "status", target: "/found", status: http.StatusFound, location: "https://example.com/found"},
+		{name: "gone", target: "/old", status: http.StatusGone},
+		{name: "path with alias", target: "/gh", status: http.StatusMovedPermanently, location: "https://github.com"},
+		{name: "alias", target: "/github", status: http.StatusMovedPermanently, location: "https://github.com"},
+		{name: "alias only", target: "/docs", status: http.StatusMovedPermanently, location: "https://example.com/docs"},
+		{name: "miss", target: "/missing", status: http.StatusNotFound},
+	})
+}
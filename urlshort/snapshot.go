@@ -0,0 +1,15 @@
+package urlshort
+
+// snapshotEntries returns the entries served, one per path and
+// sorted by it, so that building them again gives the same
+// mappings. Aliases come out as entries of their own, and hit
+// counts are left behind.
+func snapshotEntries(entries map[string]ShortenedUrl) ShortenedUrls {
+	urls := make(ShortenedUrls, 0, len(entries))
+	for _, entry := range entries {
+		entry.Paths = nil
+		entry.hits = nil
+		urls = append(urls, entry)
+	}
+	return sortedByPath(urls)
+}
@@ -0,0 +1,61 @@
+package urlshort
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// specialPath reports whether path names no resource, so that
+// it must not be matched: it is "*", the asterisk form of a
+// request for the server as a whole, or it is empty, as some
+// proxies pass it on, and no entry is mapped to "" itself.
+// Matching an empty path as usual could otherwise find the
+// entry of the root path once normalized.
+func (rd *redirector) specialPath(path string, lookup lookupFunc) bool {
+	switch path {
+	case "*":
+		return true
+	case "":
+		entry, exists := lookup("")
+		return !exists || !rd.active(entry)
+	}
+	return false
+}
+
+// serveSpecialPath answers requests whose path specialPath
+// reports, and reports whether it did. As RFC 9110 has it, an
+// OPTIONS request for "*" gets a 204 listing the methods the
+// handler allows, and any other method a 400, since "*" is no
+// url to redirect. A request with an empty path goes to the
+// fallback, without trying DefaultURL either.
+func (rd *redirector) serveSpecialPath(w http.ResponseWriter, r *http.Request, lookup lookupFunc) bool {
+	if !rd.specialPath(r.URL.Path, lookup) {
+		return false
+	}
+	if r.URL.Path == "" {
+		rd.debugMiss(w)
+		if rd.opts.OnMiss != nil {
+			rd.opts.OnMiss(r)
+		}
+		rd.log(r, rd.opts.missLevel(), "Empty path",
+			slog.Bool("fallback", true))
+		rd.fallback.ServeHTTP(w, r)
+		return true
+	}
+	if r.Method != http.MethodOptions {
+		rd.log(r, slog.LevelWarn, "Rejecting asterisk-form request",
+			slog.String("method", r.Method),
+			slog.Int("status", http.StatusBadRequest),
+			slog.Bool("fallback", false))
+		rd.error(w, r, http.StatusBadRequest)
+		return true
+	}
+	rd.log(r, rd.opts.redirectLevel(), "Answering OPTIONS",
+		slog.String("path", r.URL.Path),
+		slog.Int("status", http.StatusNoContent),
+		slog.Bool("fallback", false))
+	w.Header().Set("Allow", strings.Join(optionsAllow(rd.opts.Methods), ", "))
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
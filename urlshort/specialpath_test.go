@@ -0,0 +1,30 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSpecialPaths(t *testing.T) {
+	urls := ShortenedUrls{{Path: "/", Url: "https://example.com/home"}}
+	handler, err := UrlsHandler(urls, Options{RootURL: "https://example.com/root", Logger: discardLogger}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "OPTIONS asterisk", method: http.MethodOptions, target: "*", status: http.StatusNoContent},
+		{name: "GET asterisk", target: "*", status: http.StatusBadRequest},
+	})
+	if got := serveCase(handler, redirectCase{method: http.MethodOptions, target: "*"}).Header().Get("Allow"); got == "" {
+		t.Error("OPTIONS *: no Allow header")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.URL.Path = ""
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Location") != "" {
+		t.Errorf("empty path: got status %d and Location %q, want the fallback", rec.Code, rec.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,124 @@
+package urlshort
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	starlarktime "go.starlark.net/lib/time"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+const (
+	// starlarkTimeout bounds how long a Starlark script may run,
+	// either when it is loaded or for one call to resolve.
+	starlarkTimeout = 100 * time.Millisecond
+
+	// starlarkMaxSteps bounds the work of a Starlark script in
+	// the same places, so that a busy loop stops well before the
+	// timeout on a fast machine.
+	starlarkMaxSteps = 1_000_000
+)
+
+type starlarkRedirector struct {
+	redirector
+	resolve *starlark.Function
+}
+
+// StarlarkHandler will load script, a Starlark program, and
+// return an http.Handler that calls the resolve function it
+// defines for each request. The function gets the normalized
+// request path, and returns the url to redirect that path to,
+// with a 301, or None to call the fallback http.Handler instead.
+// The time module of go.starlark.net is predeclared, so rules
+// can depend on the date:
+//
+//	def resolve(path):
+//	    if path == "/sale" and time.now() < time.time(year = 2025, month = 1):
+//	        return "https://example.com/winter-sale"
+//	    if path.startswith("/gh/"):
+//	        return "https://github.com/" + path[len("/gh/"):]
+//	    return None
+//
+// Only this mode is supported: the script cannot list static
+// mappings itself. Write those in another format and chain the
+// handlers instead.
+//
+// Scripts run sandboxed: Starlark has no access to the
+// filesystem, the network or the environment, load statements
+// fail, and print output is logged. Loading the script and each
+// call to resolve are each stopped after 100ms or a million
+// execution steps, whichever comes first. A call that fails, is
+// stopped or returns something other than a string or None is
+// logged and treated as a miss. The globals of the script are
+// frozen once it is loaded, so calls cannot affect each other.
+//
+// An error is returned if the script does not load or does not
+// define resolve as a function of one parameter.
+func StarlarkHandler(script []byte, fallback http.Handler) (http.Handler, error) {
+	opts, _ := Options{}.withDefaults()
+	sr := &starlarkRedirector{redirector: redirector{opts: opts, fallback: fallback}}
+
+	thread := sr.thread("load")
+	defer time.AfterFunc(starlarkTimeout, func() { thread.Cancel("timeout") }).Stop()
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, "script.star", script,
+		starlark.StringDict{"time": starlarktime.Module})
+	if err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+	resolve, ok := globals["resolve"].(*starlark.Function)
+	if !ok {
+		return nil, fmt.Errorf("script does not define a resolve function")
+	}
+	if resolve.NumParams() != 1 || resolve.HasVarargs() || resolve.HasKwargs() {
+		return nil, fmt.Errorf("resolve must take exactly one parameter, the path")
+	}
+	sr.resolve = resolve
+	return sr, nil
+}
+
+func (sr *starlarkRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sr.serve(w, r, sr.lookup)
+}
+
+// Match implements Matcher.
+func (sr *starlarkRedirector) Match(r *http.Request) (string, bool) {
+	return sr.matchRequest(r, sr.lookup)
+}
+
+func (sr *starlarkRedirector) lookup(key string) (ShortenedUrl, bool) {
+	thread := sr.thread(key)
+	defer time.AfterFunc(starlarkTimeout, func() { thread.Cancel("timeout") }).Stop()
+	result, err := starlark.Call(thread, sr.resolve, starlark.Tuple{starlark.String(key)}, nil)
+	if err != nil {
+		sr.opts.logger().Error("Error while resolving path with script, treating as miss",
+			slog.String("path", key), slog.Any("error", err))
+		return ShortenedUrl{}, false
+	}
+	switch result := result.(type) {
+	case starlark.NoneType:
+		return ShortenedUrl{}, false
+	case starlark.String:
+		return ShortenedUrl{Path: key, Url: string(result)}, true
+	default:
+		sr.opts.logger().Error("Script resolved path to neither a string nor None, treating as miss",
+			slog.String("path", key), slog.String("type", result.Type()))
+		return ShortenedUrl{}, false
+	}
+}
+
+// thread returns a new Starlark thread, limited to
+// starlarkMaxSteps and unable to load modules.
+func (sr *starlarkRedirector) thread(name string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(thread *starlark.Thread, msg string) {
+			sr.opts.logger().Info("Script printed", slog.String("thread", thread.Name), slog.String("msg", msg))
+		},
+	}
+	thread.SetMaxExecutionSteps(starlarkMaxSteps)
+	return thread
+}
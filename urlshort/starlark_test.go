@@ -0,0 +1,62 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStarlarkHandler(t *testing.T) {
+	handler, err := StarlarkHandler([]byte(`
+def resolve(path):
+    if path == "/a":
+        return "https://example.com/a"
+    if path.startswith("/gh/"):
+        return "https://github.com/" + path[len("/gh/"):]
+    if path == "/number":
+        return 42
+    if path == "/fail":
+        fail("no such path")
+    if path == "/spin":
+        for i in range(100000000):
+            pass
+    if path == "/print":
+        print("printed")
+        return "https://example.com/printed"
+    return None
+`), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "computed", target: "/gh/golang", status: http.StatusMovedPermanently, location: "https://github.com/golang"},
+		{name: "print", target: "/print", status: http.StatusMovedPermanently, location: "https://example.com/printed"},
+		{name: "none is a miss", target: "/b", status: http.StatusNotFound},
+		{name: "not a string", target: "/number", status: http.StatusNotFound},
+		{name: "error", target: "/fail", status: http.StatusNotFound},
+		{name: "step cap", target: "/spin", status: http.StatusNotFound},
+	})
+}
+
+func TestStarlarkHandlerErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, script, message string
+	}{
+		{name: "syntax", script: "def resolve(path)\n    return None\n", message: "got newline"},
+		{name: "no resolve", script: "x = 1\n", message: "does not define a resolve function"},
+		{name: "not a function", script: "resolve = 1\n", message: "does not define a resolve function"},
+		{name: "two parameters", script: "def resolve(path, host):\n    return None\n", message: "exactly one parameter"},
+		{name: "varargs", script: "def resolve(*args):\n    return None\n", message: "exactly one parameter"},
+		{name: "load", script: "load(\"other.star\", \"x\")\ndef resolve(path):\n    return None\n", message: "load not implemented"},
+		{name: "step cap", script: "def spin():\n    for i in range(100000000):\n        pass\nspin()\ndef resolve(path):\n    return None\n", message: "too many steps"},
+		{name: "runtime error", script: "x = 1 // 0\ndef resolve(path):\n    return None\n", message: "division by zero"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := StarlarkHandler([]byte(tc.script), http.NotFoundHandler())
+			if err == nil || !strings.Contains(err.Error(), tc.message) {
+				t.Errorf("StarlarkHandler() = %v, want an error containing %q", err, tc.message)
+			}
+		})
+	}
+}
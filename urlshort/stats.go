@@ -0,0 +1,63 @@
+package urlshort
+
+// ConfigStats sums up a set of mappings, as returned by Stats.
+type ConfigStats struct {
+	// Entries is the number of entries, disabled ones included.
+	Entries int
+	// Paths is the number of paths the entries are served under,
+	// aliases included.
+	Paths int
+	// Aliases is the number of paths listed in Paths fields.
+	Aliases int
+	// DuplicatePaths is the number of paths listed by more than
+	// one entry, whether as a path or an alias, and whether or
+	// not the entries agree on the url.
+	DuplicatePaths int
+	// Gone is the number of entries that are gone.
+	Gone int
+	// Absolute and Relative count the urls the entries redirect
+	// to, each target counting once, by whether they name a host.
+	// Invalid counts those that cannot be parsed at all.
+	Absolute, Relative, Invalid int
+	// Hosts counts the absolute urls by their lowercase host,
+	// without any port.
+	Hosts map[string]int
+}
+
+// Stats sums up urls, such as those parsed from a config, for
+// example to chart them on a dashboard. Paths are compared once
+// decoded, as the handlers do with default options. It has no
+// side effects, and urls are left unchanged.
+func Stats(urls ShortenedUrls) ConfigStats {
+	stats := ConfigStats{Entries: len(urls), Hosts: map[string]int{}}
+	opts := Options{}
+	listed := map[string]int{}
+	for _, entry := range urls {
+		paths := entryPaths(entry)
+		stats.Paths += len(paths)
+		stats.Aliases += len(entry.Paths)
+		for _, path := range paths {
+			key := opts.entryKey(path)
+			if listed[key]++; listed[key] == 2 {
+				stats.DuplicatePaths++
+			}
+		}
+		if entry.Gone {
+			stats.Gone++
+			continue
+		}
+		for _, rawUrl := range entryUrls(entry) {
+			host, ok := destinationHost(rawUrl)
+			switch {
+			case !ok:
+				stats.Invalid++
+			case host == "":
+				stats.Relative++
+			default:
+				stats.Absolute++
+				stats.Hosts[host]++
+			}
+		}
+	}
+	return stats
+}
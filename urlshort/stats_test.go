@@ -0,0 +1,52 @@
+package urlshort
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	urls := ShortenedUrls{
+		{Path: "/a", Url: "https://Example.com:8443/a", Paths: []string{"/alias"}},
+		{Path: "/caf%C3%A9", Url: "/local"},
+		{Path: "/café", Url: "https://go.dev/x"},
+		{Path: "/split", Targets: []Target{{Url: "https://go.dev/1", Weight: 1}, {Url: "https://example.com/2", Weight: 1}}},
+		{Path: "/old", Gone: true},
+		{Path: "/bad", Url: "http://[::1"},
+	}
+	want := ConfigStats{
+		Entries:        6,
+		Paths:          7,
+		Aliases:        1,
+		DuplicatePaths: 1,
+		Gone:           1,
+		Absolute:       4,
+		Relative:       1,
+		Invalid:        1,
+		Hosts:          map[string]int{"example.com": 2, "go.dev": 2},
+	}
+	if got := Stats(urls); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+// TestStatsAgreeWithHandler checks that the paths Stats counts
+// as duplicates are those a handler serves as one.
+func TestStatsAgreeWithHandler(t *testing.T) {
+	urls := ShortenedUrls{
+		{Path: "/caf%C3%A9", Url: "https://example.com/cafe"},
+		{Path: "/café", Url: "https://example.com/cafe"},
+	}
+	if got := Stats(urls).DuplicatePaths; got != 1 {
+		t.Errorf("DuplicatePaths = %d, want 1", got)
+	}
+	handler, err := UrlsHandler(urls, Options{Logger: discardLogger}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "encoded", target: "/caf%C3%A9", status: http.StatusMovedPermanently, location: "https://example.com/cafe"},
+		{name: "other", target: "/tea", status: http.StatusNotFound},
+	})
+}
@@ -0,0 +1,83 @@
+package urlshort
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// Store is implemented by anything redirects can be looked up
+// in, so that HandlerFromStore can serve them. The handlers of
+// this package that look paths up in a map, a database, Redis
+// or bbolt implement it too, so they can be wrapped by stores of
+// your own, for example to add a cache in front of them.
+type Store interface {
+	// Lookup returns the url path is mapped to, and true, or
+	// false if it is not mapped. The path is the request path,
+	// once normalized by the handler. An error means the store
+	// could not be used, not that path is missing.
+	Lookup(ctx context.Context, path string) (url string, ok bool, err error)
+}
+
+type storeRedirector struct {
+	redirector
+	store Store
+}
+
+// HandlerFromStore will return an http.Handler that looks up
+// each request path in store, with the context of the request,
+// and redirects it with the given status to the url found.
+// If the path is not in the store, then the fallback
+// http.Handler will be called instead. Lookup errors are logged
+// and the request is treated as a miss.
+//
+// The status must be one of 301, 302, 307 or 308, otherwise an
+// error is returned. If store implements Pinger, the Ping method
+// of the handler calls it. Lookup may be called concurrently.
+func HandlerFromStore(store Store, status int, fallback http.Handler) (http.Handler, error) {
+	if err := validateStatus(status); err != nil {
+		return nil, err
+	}
+	opts, _ := Options{Status: status}.withDefaults()
+	return &storeRedirector{
+		redirector: redirector{opts: opts, fallback: fallback},
+		store:      store,
+	}, nil
+}
+
+func (sr *storeRedirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sr.serve(w, r, sr.lookupIn(r.Context(), sr.store, "store"))
+}
+
+// Match implements Matcher.
+func (sr *storeRedirector) Match(r *http.Request) (string, bool) {
+	return sr.matchRequest(r, sr.lookupIn(r.Context(), sr.store, "store"))
+}
+
+// lookupIn returns a lookupFunc finding keys in store, logging
+// any error along with name, the kind of store.
+func (rd *redirector) lookupIn(ctx context.Context, store Store, name string) lookupFunc {
+	return func(key string) (ShortenedUrl, bool) {
+		url, ok, err := store.Lookup(ctx, key)
+		if err != nil {
+			rd.opts.logger().Error("Error while looking up path in "+name,
+				slog.String("path", key), slog.Any("error", err))
+			return ShortenedUrl{}, false
+		}
+		if !ok {
+			return ShortenedUrl{}, false
+		}
+		return ShortenedUrl{Path: key, Url: url}, true
+	}
+}
+
+// Lookup implements Store, matching path the way the handler
+// does, so that prefixes and aliases are followed. Entries that
+// are gone are reported as not mapped.
+func (pr *pathRedirector) Lookup(ctx context.Context, path string) (string, bool, error) {
+	_, entry, exists := pr.match(pr.opts.normalizePath(path), pr.lookup)
+	if !exists || entry.Gone {
+		return "", false, nil
+	}
+	return pr.opts.resolve(entry.Url), true, nil
+}
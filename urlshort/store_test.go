@@ -0,0 +1,83 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// fakeStore is a Store and Pinger backed by a map, recording
+// the paths it is asked about.
+type fakeStore struct {
+	mu    sync.Mutex
+	urls  map[string]string
+	err   error
+	asked []string
+}
+
+func (s *fakeStore) Lookup(ctx context.Context, path string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.asked = append(s.asked, path)
+	if s.err != nil {
+		return "", false, s.err
+	}
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+	url, ok := s.urls[path]
+	return url, ok, nil
+}
+
+func (s *fakeStore) Ping(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func TestHandlerFromStore(t *testing.T) {
+	store := &fakeStore{urls: map[string]string{
+		"/a":  "https://example.com/a",
+		"/é":  "https://example.com/accent",
+		"/ab": "https://example.com/ab",
+	}}
+	handler, err := HandlerFromStore(store, http.StatusTemporaryRedirect, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusTemporaryRedirect, location: "https://example.com/a"},
+		{name: "decoded", target: "/%C3%A9", status: http.StatusTemporaryRedirect, location: "https://example.com/accent"},
+		{name: "miss", target: "/b", status: http.StatusNotFound},
+	})
+	if want := []string{"/a", "/é", "/b"}; !slices.Equal(store.asked, want) {
+		t.Errorf("store asked for %q, want %q", store.asked, want)
+	}
+	if err := handler.(Pinger).Ping(context.Background()); err != nil {
+		t.Errorf("Ping() = %v", err)
+	}
+}
+
+func TestHandlerFromStoreErrors(t *testing.T) {
+	store := &fakeStore{}
+	for _, status := range []int{0, http.StatusOK, http.StatusGone, 42} {
+		if _, err := HandlerFromStore(store, status, http.NotFoundHandler()); err == nil {
+			t.Errorf("HandlerFromStore(status %d) = nil error", status)
+		}
+	}
+
+	store = &fakeStore{urls: map[string]string{"/a": "https://example.com/a"}, err: errors.New("fake: down")}
+	handler, err := HandlerFromStore(store, http.StatusFound, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "lookup error is a miss", target: "/a", status: http.StatusNotFound},
+	})
+	if err := handler.(Pinger).Ping(context.Background()); !errors.Is(err, store.err) {
+		t.Errorf("Ping() = %v, want %v", err, store.err)
+	}
+}
@@ -0,0 +1,127 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// SuggestingNotFoundHandler returns an http.Handler responding
+// with a 404 that suggests the mapped path closest to the one
+// requested, to help with typos: a request for /githb gets
+// "Did you mean /github?" when /github is mapped. It is meant as
+// the fallback of a handler built from the same urls and opts,
+// so that the paths suggested are the ones that handler
+// redirects, normalized the same way. Like NotFoundHandler, it
+// answers in JSON to clients preferring it:
+//
+//	{"error":"not found","suggestion":"/github"}
+//
+// Paths are compared by Levenshtein distance, the number of
+// characters to insert, delete or replace to turn one into the
+// other. Only a path within maxDistance of the request is
+// suggested, and the closest one wins, ties going to the one
+// sorting first. A maxDistance of 2 catches most typos without
+// suggesting unrelated paths. Disabled, scheduled, expired and
+// gone entries are never suggested.
+//
+// An error is returned if maxDistance is not positive, or if
+// the urls cannot be built with opts, as UrlsHandler would.
+func SuggestingNotFoundHandler(urls ShortenedUrls, opts Options, maxDistance int) (http.Handler, error) {
+	if maxDistance <= 0 {
+		return nil, fmt.Errorf("invalid max distance %d: must be positive", maxDistance)
+	}
+	handler, err := newMapHandler(urls, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(handler.entries))
+	for key := range handler.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return &suggester{
+		rd:          &handler.redirector,
+		entries:     handler.entries,
+		keys:        keys,
+		maxDistance: maxDistance,
+	}, nil
+}
+
+type suggester struct {
+	rd          *redirector
+	entries     map[string]ShortenedUrl
+	keys        []string
+	maxDistance int
+}
+
+func (s *suggester) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	suggestion, found := s.suggest(s.rd.opts.entryKey(r.URL.Path))
+	if prefersJSON(r.Header.Values("Accept")) {
+		body := map[string]string{"error": "not found"}
+		if found {
+			body["suggestion"] = suggestion
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(body)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusNotFound)
+	io.WriteString(w, "404 page not found\n")
+	if found {
+		fmt.Fprintf(w, "Did you mean %s?\n", suggestion)
+	}
+}
+
+// suggest returns the path of the active entry closest to key,
+// if one is within the max distance.
+func (s *suggester) suggest(key string) (string, bool) {
+	best, bestDistance := "", s.maxDistance+1
+	for _, candidate := range s.keys {
+		entry := s.entries[candidate]
+		if entry.Gone || !s.rd.active(entry) {
+			continue
+		}
+		if distance := levenshtein(key, candidate, bestDistance); distance < bestDistance {
+			best, bestDistance = entry.Path, distance
+		}
+	}
+	return best, best != ""
+}
+
+// levenshtein returns the Levenshtein distance between a and b,
+// counted in runes, or limit if it is limit or more.
+func levenshtein(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff >= limit || -diff >= limit {
+		return limit
+	}
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		rowMin := i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			rowMin = min(rowMin, current[j])
+		}
+		if rowMin >= limit {
+			return limit
+		}
+		previous, current = current, previous
+	}
+	return min(previous[len(rb)], limit)
+}
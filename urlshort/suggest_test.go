@@ -0,0 +1,110 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSuggestingNotFoundHandler(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	disabled := false
+	handler, err := SuggestingNotFoundHandler(ShortenedUrls{
+		{Path: "/github", Url: "https://github.com"},
+		{Path: "/gitlab", Url: "https://gitlab.com"},
+		{Path: "/docs", Url: "https://example.com/docs"},
+		{Path: "/tie-b", Url: "https://example.com/b"},
+		{Path: "/tie-a", Url: "https://example.com/a"},
+		{Path: "/old", Gone: true},
+		{Path: "/expired", Url: "https://example.com/expired", ExpiresAt: &past},
+		{Path: "/hidden", Url: "https://example.com/hidden", Enabled: &disabled},
+	}, Options{}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name, target, suggestion string
+	}{
+		{name: "typo", target: "/githb", suggestion: "/github"},
+		{name: "closest wins", target: "/gitlb", suggestion: "/gitlab"},
+		{name: "tie goes to the first", target: "/tie-c", suggestion: "/tie-a"},
+		{name: "too far", target: "/nothing-close", suggestion: ""},
+		{name: "gone", target: "/ol", suggestion: ""},
+		{name: "expired", target: "/expire", suggestion: ""},
+		{name: "disabled", target: "/hiden", suggestion: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.target, nil))
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want 404", rr.Code)
+			}
+			body := rr.Body.String()
+			if tc.suggestion == "" {
+				if strings.Contains(body, "Did you mean") {
+					t.Errorf("body = %q, want no suggestion", body)
+				}
+				return
+			}
+			if want := "Did you mean " + tc.suggestion + "?"; !strings.Contains(body, want) {
+				t.Errorf("body = %q, want %q", body, want)
+			}
+		})
+	}
+}
+
+func TestSuggestingNotFoundHandlerJSON(t *testing.T) {
+	handler, err := SuggestingNotFoundHandler(ShortenedUrls{{Path: "/github", Url: "https://github.com"}}, Options{}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/githb", nil)
+	r.Header.Set("Accept", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, r)
+	var body map[string]string
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["error"] != "not found" || body["suggestion"] != "/github" {
+		t.Errorf("body = %v", body)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+}
+
+func TestSuggestingNotFoundHandlerErrors(t *testing.T) {
+	if _, err := SuggestingNotFoundHandler(nil, Options{}, 0); err == nil {
+		t.Error("SuggestingNotFoundHandler(max distance 0) = nil error")
+	}
+	_, err := SuggestingNotFoundHandler(ShortenedUrls{
+		{Path: "/a", Url: "https://example.com/1"},
+		{Path: "/a", Url: "https://example.com/2"},
+	}, Options{}, 2)
+	if err == nil {
+		t.Error("SuggestingNotFoundHandler(duplicate) = nil error")
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	for _, tc := range []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{a: "kitten", b: "sitting", limit: 10, want: 3},
+		{a: "", b: "abc", limit: 10, want: 3},
+		{a: "same", b: "same", limit: 10, want: 0},
+		{a: "héllo", b: "hello", limit: 10, want: 1},
+		{a: "kitten", b: "sitting", limit: 2, want: 2},
+		{a: "a", b: "abcdef", limit: 3, want: 3},
+	} {
+		if got := levenshtein(tc.a, tc.b, tc.limit); got != tc.want {
+			t.Errorf("levenshtein(%q, %q, %d) = %d, want %d", tc.a, tc.b, tc.limit, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package urlshort
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// TSVHandler will parse the provided tab-separated values and
+// then return an http.Handler that will attempt to map any
+// paths to their corresponding URL. If the path is not provided
+// in the TSV, then the fallback http.Handler will be called
+// instead.
+//
+// TSV is expected to have two columns separated by a tab, with
+// an optional header row, which is skipped when its first
+// column is "path":
+//
+//	path	url
+//	/some-path	https://www.some-url.com/demo
+//
+// The only errors that can be returned all related to having
+// invalid TSV data, including rows without exactly two columns,
+// or a path that points to two different urls.
+func TSVHandler(tsvInput []byte, fallback http.Handler) (http.Handler, error) {
+	handler, _, err := TSVHandlerWithUrls(tsvInput, fallback)
+	return handler, err
+}
+
+// TSVHandlerWithUrls works like TSVHandler, but also returns
+// the ShortenedUrls parsed from the TSV.
+func TSVHandlerWithUrls(tsvInput []byte, fallback http.Handler) (http.Handler, ShortenedUrls, error) {
+	parsedTsv, err := parseTSV(tsvInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := UrlsHandler(parsedTsv, Options{}, fallback)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, parsedTsv, nil
+}
+
+func parseTSV(tsvInput []byte) (ShortenedUrls, error) {
+	tsvInput, err := prepareInput(tsvInput)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(tsvInput))
+	reader.Comma = '\t'
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		slog.Error("Error: " + err.Error())
+		return nil, err
+	}
+
+	var urls ShortenedUrls
+	for i, record := range records {
+		if i == 0 && strings.TrimSpace(record[0]) == "path" {
+			continue
+		}
+		if len(record) != 2 {
+			err := fmt.Errorf("tsv row %d: expected 2 columns, got %d", i+1, len(record))
+			slog.Error("Error: " + err.Error())
+			return nil, err
+		}
+		urls = append(urls, ShortenedUrl{
+			Path: strings.TrimSpace(record[0]),
+			Url:  strings.TrimSpace(record[1]),
+		})
+	}
+
+	return urls, nil
+}
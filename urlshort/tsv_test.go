@@ -0,0 +1,52 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTSVHandler(t *testing.T) {
+	handler, urls, err := TSVHandlerWithUrls([]byte("path\turl\n/a\thttps://example.com/a\n /spaced \t https://example.com/spaced \n"), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 2 {
+		t.Errorf("parsed %d urls, want 2", len(urls))
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "mapped", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "trimmed", target: "/spaced", status: http.StatusMovedPermanently, location: "https://example.com/spaced"},
+		{name: "header skipped", target: "/path", status: http.StatusNotFound},
+		{name: "miss", target: "/b", status: http.StatusNotFound},
+	})
+
+	// Without a header row, the first row is a mapping.
+	handler, err = TSVHandler([]byte("/a\thttps://example.com/a\n"), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRedirects(t, handler, []redirectCase{
+		{name: "no header", target: "/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+	})
+}
+
+func TestTSVHandlerErrors(t *testing.T) {
+	_, err := TSVHandler([]byte("/a\thttps://example.com/a\n/b\thttps://example.com/b\textra\n"), http.NotFoundHandler())
+	if err == nil || !strings.Contains(err.Error(), "row 2") {
+		t.Errorf("TSVHandler(extra column) = %v, want an error naming row 2", err)
+	}
+	for _, tc := range []struct {
+		name, input string
+	}{
+		{name: "one column", input: "/a\n"},
+		{name: "bare quote", input: "/a\thttps://example.com/\"a\n"},
+		{name: "duplicate", input: "/a\thttps://example.com/1\n/a\thttps://example.com/2\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := TSVHandler([]byte(tc.input), http.NotFoundHandler()); err == nil {
+				t.Error("TSVHandler() = nil error")
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Package urlshortotel traces the handlers of package urlshort
+// with OpenTelemetry. It lives in its own package so that
+// urlshort does not depend on OpenTelemetry.
+package urlshortotel
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"urlshort/urlshort"
+)
+
+// tracerName is the name of the tracer spans are started with.
+const tracerName = "urlshort/urlshort/urlshortotel"
+
+// TracedHandler works like urlshort.MapHandlerWithOptions, and
+// also records a span for each request, using tp. See Trace and
+// Instrument for what the spans hold. The handler implements
+// urlshort.Matcher, as Trace describes.
+func TracedHandler(pathsToUrls map[string]string, opts urlshort.Options, tp trace.TracerProvider, fallback http.Handler) (http.Handler, error) {
+	handler, err := urlshort.MapHandlerWithOptions(pathsToUrls, Instrument(opts), fallback)
+	if err != nil {
+		return nil, err
+	}
+	return Trace(handler, tp), nil
+}
+
+// Trace returns an http.Handler that records a span named
+// urlshort.serve around each request it passes to next, with
+// the status of the response as the http.response.status_code
+// attribute. The trace context of the request is taken from its
+// headers, using the global propagator, so the span joins the
+// trace of the caller. Responses with a 5xx status mark the
+// span as failed.
+//
+// Wrap a handler built with options from Instrument to also get
+// the matched path and url on the span.
+//
+// If next implements urlshort.Matcher, so does the handler
+// returned, so that it can still be chained. Calls to Match are
+// passed to next and record no span.
+func Trace(next http.Handler, tp trace.TracerProvider) http.Handler {
+	th := &tracedHandler{next: next, tracer: tp.Tracer(tracerName)}
+	if matcher, ok := next.(urlshort.Matcher); ok {
+		return &tracedMatcher{tracedHandler: th, matcher: matcher}
+	}
+	return th
+}
+
+// tracedHandler is the http.Handler returned by Trace.
+type tracedHandler struct {
+	next   http.Handler
+	tracer trace.Tracer
+}
+
+func (th *tracedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := th.tracer.Start(ctx, "urlshort.serve",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		))
+	defer span.End()
+
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	th.next.ServeHTTP(sw, r.WithContext(ctx))
+	span.SetAttributes(attribute.Int("http.response.status_code", sw.status))
+	if sw.status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(sw.status))
+	}
+}
+
+// tracedMatcher is the handler returned by Trace when next
+// implements urlshort.Matcher.
+type tracedMatcher struct {
+	*tracedHandler
+	matcher urlshort.Matcher
+}
+
+// Match implements urlshort.Matcher.
+func (tm *tracedMatcher) Match(r *http.Request) (string, bool) {
+	return tm.matcher.Match(r)
+}
+
+// Instrument returns a copy of opts that adds the outcome of
+// each request to the span in its context, as started by Trace.
+// Any OnRedirect and OnMiss hooks already set in opts are still
+// called.
+//
+// The attributes added are:
+//
+//	urlshort.match  the key of the matched entry
+//	urlshort.url    the url the request is sent to
+//	urlshort.miss   true for requests no entry matched
+func Instrument(opts urlshort.Options) urlshort.Options {
+	onRedirect, onMiss := opts.OnRedirect, opts.OnMiss
+	opts.OnRedirect = func(r *http.Request, key, url string) {
+		trace.SpanFromContext(r.Context()).SetAttributes(
+			attribute.String("urlshort.match", key),
+			attribute.String("urlshort.url", url),
+			attribute.Bool("urlshort.miss", false),
+		)
+		if onRedirect != nil {
+			onRedirect(r, key, url)
+		}
+	}
+	opts.OnMiss = func(r *http.Request) {
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.Bool("urlshort.miss", true))
+		if onMiss != nil {
+			onMiss(r)
+		}
+	}
+	return opts
+}
+
+// statusWriter records the status written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if !sw.wroteHeader {
+		sw.status = status
+		sw.wroteHeader = true
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	sw.wroteHeader = true
+	return sw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying
+// writer, for example to flush proxied responses.
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,142 @@
+package urlshortotel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"urlshort/urlshort"
+)
+
+// newRecorder returns a tracer provider recording every span it
+// ends in the returned recorder.
+func newRecorder() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)), recorder
+}
+
+// attributes returns the attributes of span by key.
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestTracedHandler(t *testing.T) {
+	tp, recorder := newRecorder()
+	handler, err := TracedHandler(map[string]string{"/a": "https://example.com/a"}, urlshort.Options{}, tp, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"/a", "/missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	for _, tc := range []struct {
+		span sdktrace.ReadOnlySpan
+		want map[attribute.Key]attribute.Value
+	}{
+		{span: spans[0], want: map[attribute.Key]attribute.Value{
+			"http.request.method":       attribute.StringValue(http.MethodGet),
+			"url.path":                  attribute.StringValue("/a"),
+			"http.response.status_code": attribute.IntValue(http.StatusMovedPermanently),
+			"urlshort.match":            attribute.StringValue("/a"),
+			"urlshort.url":              attribute.StringValue("https://example.com/a"),
+			"urlshort.miss":             attribute.BoolValue(false),
+		}},
+		{span: spans[1], want: map[attribute.Key]attribute.Value{
+			"url.path":                  attribute.StringValue("/missing"),
+			"http.response.status_code": attribute.IntValue(http.StatusNotFound),
+			"urlshort.miss":             attribute.BoolValue(true),
+		}},
+	} {
+		if tc.span.Name() != "urlshort.serve" {
+			t.Errorf("span name = %q, want urlshort.serve", tc.span.Name())
+		}
+		got := attributes(tc.span)
+		for key, want := range tc.want {
+			if got[key] != want {
+				t.Errorf("%s: attribute %s = %v, want %v", tc.span.Name(), key, got[key].Emit(), want.Emit())
+			}
+		}
+	}
+	if _, ok := attributes(spans[1])["urlshort.match"]; ok {
+		t.Error("miss span has a urlshort.match attribute")
+	}
+}
+
+func TestTracedHandlerMatcher(t *testing.T) {
+	tp, recorder := newRecorder()
+	handler, err := TracedHandler(map[string]string{"/a": "https://example.com/a"}, urlshort.Options{}, tp, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	matcher, ok := handler.(urlshort.Matcher)
+	if !ok {
+		t.Fatal("TracedHandler() does not implement urlshort.Matcher")
+	}
+	if url, matched := matcher.Match(httptest.NewRequest(http.MethodGet, "/a", nil)); !matched || url != "https://example.com/a" {
+		t.Errorf("Match(/a) = %q, %v", url, matched)
+	}
+	if len(recorder.Ended()) != 0 {
+		t.Error("Match recorded a span")
+	}
+
+	// A handler that is not a Matcher is not made into one.
+	traced := Trace(http.NotFoundHandler(), tp)
+	if _, ok := traced.(urlshort.Matcher); ok {
+		t.Error("Trace(http.NotFoundHandler()) implements urlshort.Matcher")
+	}
+}
+
+func TestTraceErrorStatus(t *testing.T) {
+	tp, recorder := newRecorder()
+	handler := Trace(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusBadGateway)
+	}), tp)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if status := spans[0].Status(); status.Code != codes.Error {
+		t.Errorf("span status = %v, want an error", status)
+	}
+}
+
+func TestTraceJoinsCallerTrace(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	tp, recorder := newRecorder()
+	handler := Trace(http.NotFoundHandler(), tp)
+	r := httptest.NewRequest(http.MethodGet, "/a", nil)
+	r.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace id = %s, want the one of the caller", got)
+	}
+	if got := spans[0].Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("parent span id = %s, want the one of the caller", got)
+	}
+}
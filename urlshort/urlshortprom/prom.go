@@ -0,0 +1,98 @@
+// Package urlshortprom exports Prometheus metrics for the
+// handlers of package urlshort. It lives in its own package so
+// that urlshort does not depend on the Prometheus client.
+package urlshortprom
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"urlshort/urlshort"
+)
+
+// InstrumentedHandler works like urlshort.MapHandlerWithOptions,
+// and also counts redirects and misses, registering the
+// counters with reg. See Instrument for the metrics exported.
+func InstrumentedHandler(pathsToUrls map[string]string, opts urlshort.Options, reg prometheus.Registerer, fallback http.Handler) (http.Handler, error) {
+	opts, err := Instrument(opts, reg)
+	if err != nil {
+		return nil, err
+	}
+	return urlshort.MapHandlerWithOptions(pathsToUrls, opts, fallback)
+}
+
+// Instrument returns a copy of opts that counts redirects and
+// misses of the handler built with it, and registers the
+// counters with reg. Any OnRedirect and OnMiss hooks already
+// set in opts are still called.
+//
+// The metrics exported are:
+//
+//	urlshort_redirects_total{path="..."}  redirects, by matched path
+//	urlshort_fallback_total               requests no entry matched
+//
+// Build a single handler per registerer, since registering the
+// same metrics twice fails.
+func Instrument(opts urlshort.Options, reg prometheus.Registerer) (urlshort.Options, error) {
+	redirects := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "urlshort_redirects_total",
+		Help: "Number of requests redirected, by matched path.",
+	}, []string{"path"})
+	misses := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "urlshort_fallback_total",
+		Help: "Number of requests that matched no path.",
+	})
+	if err := reg.Register(redirects); err != nil {
+		return opts, err
+	}
+	if err := reg.Register(misses); err != nil {
+		reg.Unregister(redirects)
+		return opts, err
+	}
+
+	onRedirect, onMiss := opts.OnRedirect, opts.OnMiss
+	opts.OnRedirect = func(r *http.Request, key, url string) {
+		redirects.WithLabelValues(key).Inc()
+		if onRedirect != nil {
+			onRedirect(r, key, url)
+		}
+	}
+	opts.OnMiss = func(r *http.Request) {
+		misses.Inc()
+		if onMiss != nil {
+			onMiss(r)
+		}
+	}
+	return opts, nil
+}
+
+// InstrumentDuration returns a copy of opts that records how
+// long the handler built with it takes to serve each request,
+// and registers the histogram with reg. It can be combined with
+// Instrument. Any OnServed hook already set in opts is still
+// called.
+//
+// The metric exported is:
+//
+//	urlshort_request_duration_seconds  time taken to serve a request
+func InstrumentDuration(opts urlshort.Options, reg prometheus.Registerer) (urlshort.Options, error) {
+	durations := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "urlshort_request_duration_seconds",
+		Help:    "Time taken to serve a request, including the lookup.",
+		Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10),
+	})
+	if err := reg.Register(durations); err != nil {
+		return opts, err
+	}
+
+	onServed := opts.OnServed
+	opts.OnServed = func(r *http.Request, elapsed time.Duration) {
+		durations.Observe(elapsed.Seconds())
+		if onServed != nil {
+			onServed(r, elapsed)
+		}
+	}
+	return opts, nil
+}
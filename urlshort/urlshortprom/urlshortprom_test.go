@@ -0,0 +1,128 @@
+package urlshortprom
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"urlshort/urlshort"
+)
+
+// registry is a prometheus.Registry that also keeps the
+// collectors registered with it, so that tests can read them.
+type registry struct {
+	*prometheus.Registry
+	collectors []prometheus.Collector
+}
+
+func newRegistry() *registry {
+	return &registry{Registry: prometheus.NewRegistry()}
+}
+
+func (r *registry) Register(c prometheus.Collector) error {
+	if err := r.Registry.Register(c); err != nil {
+		return err
+	}
+	r.collectors = append(r.collectors, c)
+	return nil
+}
+
+func serve(handler http.Handler, targets ...string) {
+	for _, target := range targets {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+}
+
+func TestInstrumentedHandler(t *testing.T) {
+	reg := newRegistry()
+	var hooked, missed int
+	opts := urlshort.Options{
+		OnRedirect: func(*http.Request, string, string) { hooked++ },
+		OnMiss:     func(*http.Request) { missed++ },
+	}
+	handler, err := InstrumentedHandler(map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "https://example.com/b",
+	}, opts, reg, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve(handler, "/a", "/a", "/b", "/missing", "/other")
+
+	if len(reg.collectors) != 2 {
+		t.Fatalf("registered %d collectors, want 2", len(reg.collectors))
+	}
+	redirects := reg.collectors[0].(*prometheus.CounterVec)
+	misses := reg.collectors[1].(prometheus.Counter)
+	if got := testutil.ToFloat64(redirects.WithLabelValues("/a")); got != 2 {
+		t.Errorf("redirects of /a = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(redirects.WithLabelValues("/b")); got != 1 {
+		t.Errorf("redirects of /b = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(misses); got != 2 {
+		t.Errorf("misses = %v, want 2", got)
+	}
+	if hooked != 3 || missed != 2 {
+		t.Errorf("hooks called %d and %d times, want 3 and 2", hooked, missed)
+	}
+}
+
+func TestInstrumentDuplicateRegistration(t *testing.T) {
+	reg := newRegistry()
+	if _, err := Instrument(urlshort.Options{}, reg); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Instrument(urlshort.Options{}, reg)
+	var already prometheus.AlreadyRegisteredError
+	if !errors.As(err, &already) {
+		t.Fatalf("Instrument() twice = %v, want prometheus.AlreadyRegisteredError", err)
+	}
+
+	// When only the second counter clashes, the first one is
+	// unregistered again, so nothing is left half registered.
+	fresh := prometheus.NewRegistry()
+	fresh.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "urlshort_fallback_total",
+		Help: "Number of requests that matched no path.",
+	}))
+	if _, err := Instrument(urlshort.Options{}, fresh); err == nil {
+		t.Fatal("Instrument() with a clashing counter = nil error")
+	}
+	if err := fresh.Register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "urlshort_redirects_total",
+		Help: "Number of requests redirected, by matched path.",
+	}, []string{"path"})); err != nil {
+		t.Errorf("urlshort_redirects_total left registered: %v", err)
+	}
+}
+
+func TestInstrumentDuration(t *testing.T) {
+	reg := newRegistry()
+	var served int
+	opts, err := InstrumentDuration(urlshort.Options{
+		OnServed: func(*http.Request, time.Duration) { served++ },
+	}, reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, err := urlshort.MapHandlerWithOptions(map[string]string{"/a": "https://example.com/a"}, opts, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve(handler, "/a", "/missing")
+	if got := testutil.CollectAndCount(reg.collectors[0]); got != 1 {
+		t.Errorf("collected %d histograms, want 1", got)
+	}
+	if served != 2 {
+		t.Errorf("OnServed called %d times, want 2", served)
+	}
+	if _, err := InstrumentDuration(urlshort.Options{}, reg); err == nil {
+		t.Error("InstrumentDuration() twice = nil error")
+	}
+}
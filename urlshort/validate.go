@@ -0,0 +1,106 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// validateUrls checks that every entry has a path and points
+// to an absolute http or https url once passed through resolve.
+// It returns a *ConfigError with one problem per invalid entry,
+// or nil if they are all valid.
+func validateUrls(urls ShortenedUrls, resolve func(string) string) error {
+	configErr := &ConfigError{}
+	for i, entry := range urls {
+		checkEntry(configErr, i, entry, resolve)
+	}
+	return configErr.err()
+}
+
+// validateConfig runs every check on urls that building a
+// handler with default options would, with every url required
+// to be absolute, and returns the *ConfigError listing every
+// problem found, or nil.
+func validateConfig(urls ShortenedUrls) error {
+	opts, _ := Options{ValidateURLs: true}.withDefaults()
+	_, err := opts.buildEntries(urls)
+	return err
+}
+
+// checkEntry records the problems with the path and urls of
+// the entry at index, resolving urls with resolve before
+// checking them. Empty urls are reported as such rather than
+// resolved. It returns false if the entry has no path at all,
+// neither in Path nor in Paths.
+func checkEntry(configErr *ConfigError, index int, entry ShortenedUrl, resolve func(string) string) bool {
+	if strings.TrimSpace(entry.Path) == "" && len(entry.Paths) == 0 {
+		configErr.add(index, "", "empty path")
+		return false
+	}
+	for _, alias := range entry.Paths {
+		if strings.TrimSpace(alias) == "" {
+			configErr.add(index, entry.Path, "empty alias")
+		}
+	}
+	if entry.Gone {
+		return true
+	}
+	for _, url := range entryUrls(entry) {
+		if strings.TrimSpace(url) == "" {
+			configErr.add(index, entry.Path, "empty url")
+			continue
+		}
+		if pointsToItself(entry, resolve(url)) {
+			configErr.add(index, entry.Path, "url '%s' redirects to its own path", url)
+			continue
+		}
+		if err := validateUrl(resolve(url)); err != nil {
+			configErr.add(index, entry.Path, "%s", err)
+		}
+	}
+	return true
+}
+
+// entryUrls returns the urls entry can redirect to: those of
+// its targets if it has any, or else its url.
+func entryUrls(entry ShortenedUrl) []string {
+	if len(entry.Targets) == 0 {
+		return []string{entry.Url}
+	}
+	urls := make([]string, len(entry.Targets))
+	for i, target := range entry.Targets {
+		urls[i] = target.Url
+	}
+	return urls
+}
+
+func validateUrl(rawUrl string) error {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return fmt.Errorf("invalid url '%s': %w", rawUrl, err)
+	}
+	if parsed.Scheme == "" && parsed.Host != "" {
+		return fmt.Errorf("invalid url '%s': scheme-relative, needs a scheme such as from DefaultScheme", rawUrl)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("invalid url '%s': scheme must be http or https", rawUrl)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("invalid url '%s': missing host", rawUrl)
+	}
+	return nil
+}
+
+// checkStatuses records a problem for every entry with a status
+// that is not a redirect status.
+func checkStatuses(configErr *ConfigError, urls ShortenedUrls) {
+	for i, entry := range urls {
+		if entry.Status == 0 {
+			continue
+		}
+		if err := validateStatus(entry.Status); err != nil {
+			configErr.add(i, entry.Path, "%s", err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package urlshort
+
+import (
+	"math/rand"
+	"slices"
+	"sync"
+)
+
+// Target is one of several urls an entry can redirect to, with
+// the weight used to pick between them.
+type Target struct {
+	Url    string  `json:"url" msgpack:"url" yaml:"url" toml:"url" hcl:"url" xml:"url"`
+	Weight float64 `json:"weight" msgpack:"weight" yaml:"weight" toml:"weight" hcl:"weight,optional" xml:"weight"`
+}
+
+// pickTarget chooses one of targets at random, using weights
+// as described on ShortenedUrl.Targets.
+func (o Options) pickTarget(targets []Target) string {
+	random := o.Random
+	if random == nil {
+		random = rand.Float64
+	}
+	return targets[pickWeighted(len(targets), func(i int) float64 {
+		return targets[i].Weight
+	}, random)].Url
+}
+
+// lockedRandom returns a func calling random, one call at a
+// time, so that a source that is not safe for concurrent use,
+// such as a seeded *rand.Rand, can be shared by requests.
+func lockedRandom(random func() float64) func() float64 {
+	var mu sync.Mutex
+	return func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return random()
+	}
+}
+
+// pickWeighted returns the index of one of n choices, picked at
+// random in proportion to their weight. Choices with a zero
+// weight are never picked, unless every weight is zero, in
+// which case all choices are equally likely.
+func pickWeighted(n int, weight func(i int) float64, random func() float64) int {
+	var total float64
+	for i := 0; i < n; i++ {
+		total += weight(i)
+	}
+	if total == 0 {
+		return int(random()*float64(n)) % n
+	}
+
+	r := random() * total
+	for i := 0; i < n; i++ {
+		if weight(i) == 0 {
+			continue
+		}
+		r -= weight(i)
+		if r < 0 {
+			return i
+		}
+	}
+	// Rounding can leave r just above zero after the last choice.
+	for i := n - 1; ; i-- {
+		if weight(i) != 0 {
+			return i
+		}
+	}
+}
+
+// checkTargets records a problem for every entry with a target
+// with a negative weight.
+func checkTargets(configErr *ConfigError, urls ShortenedUrls) {
+	for i, entry := range urls {
+		for _, target := range entry.Targets {
+			if target.Weight < 0 {
+				configErr.add(i, entry.Path, "target '%s' has negative weight %g", target.Url, target.Weight)
+			}
+		}
+	}
+}
+
+// sameDestination reports whether a and b redirect to the same
+// place, so that listing both under one path is not a conflict.
+func sameDestination(a, b ShortenedUrl) bool {
+	return a.Gone == b.Gone && a.Url == b.Url && a.Status == b.Status && a.MaxHits == b.MaxHits && slices.Equal(a.Targets, b.Targets)
+}
@@ -0,0 +1,41 @@
+package urlshort
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestSeededRandomConcurrentUse is meant to be run with -race:
+// a seeded *rand.Rand is not safe for concurrent use, so the
+// handler must serialize its calls.
+func TestSeededRandomConcurrentUse(t *testing.T) {
+	urls := ShortenedUrls{{
+		Path:    "/a",
+		Targets: []Target{{Url: "https://a.example.com", Weight: 1}, {Url: "https://b.example.com", Weight: 1}},
+	}}
+	opts := Options{Random: rand.New(rand.NewSource(1)).Float64, Logger: discardLogger}
+	handler, err := UrlsHandler(urls, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/a", nil))
+				if rec.Code != http.StatusMovedPermanently {
+					t.Errorf("status = %d, want %d", rec.Code, http.StatusMovedPermanently)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}